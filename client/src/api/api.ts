@@ -1,6 +1,10 @@
 import { Config } from '@/api/config';
+import { HotkeyEvent } from '@/api/hotkey';
 import { Credits, Info } from '@/api/info';
+import { Operation } from '@/api/operation';
 import { OrganizerStatus } from '@/api/organizer';
+import { Plan } from '@/api/plan';
+import { Session } from '@/api/session';
 
 /**
  * API represents the API for the server.
@@ -31,6 +35,28 @@ export interface OrganizerAPI {
      */
     restoreConfig: () => Promise<Config>;
 
+    /**
+     * resumeSession restores the latest organizing session, starting the organizer.
+     * The session's configuration must still be valid.
+     * Files already moved away from the source directory are no longer organized,
+     * while the other files already decided are placed before the current file.
+     */
+    resumeSession: () => Promise<OrganizerStatus>;
+
+    /**
+     * latestSession returns the latest organizing session, which can be resumed
+     * with resumeSession, or null if there is none.
+     * The latest session is not overwritten by loadConfig until it is resumed
+     * or discarded with discardSession.
+     */
+    latestSession: () => Promise<Session | null>;
+
+    /**
+     * discardSession removes the latest organizing session,
+     * which is otherwise kept until it is resumed.
+     */
+    discardSession: () => Promise<void>;
+
     /**
      * loadConfig loads the given configuration, which must be valid, starting the organizer.
      */
@@ -39,6 +65,9 @@ export interface OrganizerAPI {
     /**
      * dropConfigWait removes the current configuration, if any, stopping the organizer.
      * All submitted operations, pending or in progress, are completed.
+     * Operations staged in deferred mode are discarded unless committed first with commitDecisions.
+     * The returned status reports the operations of the removed configuration,
+     * so that the operations failed while completing them are not lost.
      */
     dropConfigWait: () => Promise<OrganizerStatus>;
 
@@ -58,9 +87,141 @@ export interface OrganizerAPI {
      * If the hotkey is the space character, the organizer advances to the next file.
      * If the hotkey is associated to a destination directory, the organizer creates
      * a file operation for the current file and then advances to the next file.
+     * If the current file was already sent to a destination directory,
+     * the hotkey associated to a destination directory is ignored.
+     * In deferred and dry run modes, operations are only staged and the decision
+     * on a file can be changed by revisiting it, until commitDecisions is called.
+     * If the hotkey is pressed with the shift modifier, for example "Shift+a",
+     * and no destination directory is associated to the modified hotkey,
+     * the operation type is inverted, from move to copy or from copy to move,
+     * for the current file only.
      * If the hotkey is unrecognized, the organizer does nothing.
      */
     handleHotkey: (hotkey: string) => Promise<OrganizerStatus>;
+
+    /**
+     * handleHotkeyEvent handles the action corresponding to the pressed hotkey
+     * and modifier keys, like handleHotkey.
+     * If the event reports the typed character, for example "A" or "!",
+     * a destination directory associated to that character takes precedence
+     * over inverting the operation type of the unmodified key.
+     */
+    handleHotkeyEvent: (event: HotkeyEvent) => Promise<OrganizerStatus>;
+
+    /**
+     * handleHotkeys sends the current file to all the destination directories
+     * associated to the given hotkeys with a single decision, and then advances
+     * to the next file.
+     * The operation for each destination directory is determined as in handleHotkey,
+     * except that the file is copied instead of moved to all the destination directories
+     * but the last one; the last operation is executed only after all the others have finished.
+     * If a hotkey is unrecognized or if two hotkeys are associated
+     * to the same destination directory, the organizer does nothing.
+     */
+    handleHotkeys: (hotkeys: string[]) => Promise<OrganizerStatus>;
+
+    /**
+     * previousFile goes back to the previous file, if any.
+     */
+    previousFile: () => Promise<OrganizerStatus>;
+
+    /**
+     * goToFile makes the file at the given zero-based index the current file.
+     */
+    goToFile: (index: number) => Promise<OrganizerStatus>;
+
+    /**
+     * goToFileByName makes the next file whose name matches the given
+     * shell pattern the current file.
+     * The search starts after the current file and wraps around.
+     */
+    goToFileByName: (pattern: string) => Promise<OrganizerStatus>;
+
+    /**
+     * renameCurrentFile renames the current file, in its directory, to the given name.
+     * The new name must be a valid file name not used by another file.
+     * Files with operations already submitted cannot be renamed.
+     */
+    renameCurrentFile: (newName: string) => Promise<OrganizerStatus>;
+
+    /**
+     * commitDecisions submits to the worker pool all the operations
+     * staged in deferred mode.
+     * Operations staged in dry run mode cannot be committed.
+     */
+    commitDecisions: () => Promise<OrganizerStatus>;
+
+    /**
+     * executionPlan returns the plan of the operations staged or pending,
+     * predicting the name collisions at their destinations.
+     * In dry run mode, the plan contains all the operations decided so far.
+     */
+    executionPlan: () => Promise<Plan>;
+
+    /**
+     * exportPlanScript returns the execution plan as a POSIX shell script.
+     */
+    exportPlanScript: () => Promise<string>;
+
+    /**
+     * exportPlanCSV returns the execution plan in CSV format, with a src,dst,op header.
+     */
+    exportPlanCSV: () => Promise<string>;
+
+    /**
+     * importPlanCSV submits to the worker pool the operations listed in the given plan,
+     * in the format returned by exportPlanCSV.
+     * Imported operations are tracked like the other operations
+     * and can be retried if they fail.
+     */
+    importPlanCSV: (plan: string) => Promise<OrganizerStatus>;
+
+    /**
+     * undo reverts the last decision taken with handleHotkey, going back to the previous file.
+     * If the decision submitted an operation, the operation is canceled if still staged or pending
+     * or reverted if already executed: moved files are moved back to their
+     * original path, trashed files are restored from the trash and copies and links are removed.
+     * Staged operations replaced by the decision are staged again.
+     * Decisions whose operations were submitted in a previous session cannot be undone.
+     */
+    undo: () => Promise<OrganizerStatus>;
+
+    /**
+     * retryOperation submits again the failed operation with the given ID.
+     * If dstDir is not empty, the operation's destination is moved to that directory,
+     * keeping the subfolders and the name rendered by the destination's templates;
+     * trash operations cannot be moved.
+     */
+    retryOperation: (id: number, dstDir: string) => Promise<OrganizerStatus>;
+
+    /**
+     * retryAllFailed submits again all the failed operations.
+     */
+    retryAllFailed: () => Promise<OrganizerStatus>;
+
+    /**
+     * dismissOperation marks the failed operation with the given ID as dismissed,
+     * removing it from the list of failed operations.
+     */
+    dismissOperation: (id: number) => Promise<OrganizerStatus>;
+
+    /**
+     * unfinishedOperations returns the operations submitted in previous sessions
+     * that were never completed, for example because the application was closed
+     * before executing them or because it crashed.
+     */
+    unfinishedOperations: () => Promise<Operation[]>;
+
+    /**
+     * replayUnfinished submits again, using the current configuration,
+     * the operations returned by unfinishedOperations.
+     */
+    replayUnfinished: () => Promise<OrganizerStatus>;
+
+    /**
+     * discardUnfinished forgets the operations returned by unfinishedOperations.
+     */
+    discardUnfinished: () => Promise<OrganizerStatus>;
 }
 
 /**
@@ -1,4 +1,4 @@
-import { OpType } from '@/api/operation';
+import { CollisionPolicy, OpType } from '@/api/operation';
 
 export interface Config {
     id: number;
@@ -12,6 +12,40 @@ export interface ConfigSrc {
     dir: string;
     includeSubdirs: boolean;
     defaultOpType: OpType;
+    filters?: SrcFilters;
+    sort?: SrcSort;
+    sortSeed?: number;
+    checkStable?: boolean;
+    stableSeconds?: number;
+    extraDirs?: SrcDir[];
+}
+
+export interface SrcDir {
+    dir: string;
+    includeSubdirs: boolean;
+    defaultOpType: OpType;
+}
+
+export enum SrcSort {
+    Path = '',
+    Name = 'name',
+    MtimeAsc = 'mtimeAsc',
+    MtimeDesc = 'mtimeDesc',
+    Size = 'size',
+    Ext = 'ext',
+    Dir = 'dir',
+    Random = 'random',
+}
+
+export interface SrcFilters {
+    include: string[];
+    exclude: string[];
+    extensions: string[];
+    minSize: number;
+    maxSize: number;
+    mtimeFrom: string;
+    mtimeTo: string;
+    skipHidden: boolean;
 }
 
 export interface ConfigDst {
@@ -21,11 +55,17 @@ export interface ConfigDst {
 export interface DstDir {
     hotkey: string;
     dir: string;
+    op?: OpType;
+    rename?: string;
+    subpath?: string;
 }
 
 export interface ConfigOps {
     numWorkers: number;
     maxTries: number;
+    deferred: boolean;
+    dryRun: boolean;
+    collisionPolicy?: CollisionPolicy;
 }
 
 export interface ConfigValidationError {
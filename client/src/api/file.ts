@@ -1,9 +1,11 @@
 export interface File {
     id: number;
+    srcId: number;
     name: string;
     dir: string;
     path: string;
     ext: string;
     size: number;
     url: string;
+    gone: boolean;
 }
@@ -0,0 +1,10 @@
+/**
+ * HotkeyEvent represents a pressed hotkey together with the modifier keys held.
+ * key is the unmodified key, while raw is the character actually typed,
+ * for example "A" or "!" when the shift key is held.
+ */
+export interface HotkeyEvent {
+    key: string;
+    shift: boolean;
+    raw: string;
+}
@@ -1,4 +1,52 @@
+/**
+ * Operation represents an organizer's operation.
+ */
+export interface Operation {
+    id: number;
+    fileId: number;
+    op: OpType;
+    srcPath: string;
+    dstPath: string;
+    maxTries: number;
+    collisionPolicy: CollisionPolicy;
+    status: OpStatus;
+    finalDstPath: string;
+    outcome: OpOutcome;
+    err: string;
+}
+
 export enum OpType {
     Copy = 'copy',
     Move = 'move',
+    Trash = 'trash',
+    Symlink = 'symlink',
+    Hardlink = 'hardlink',
+}
+
+export enum CollisionPolicy {
+    Rename = 'rename',
+    Skip = 'skip',
+    Overwrite = 'overwrite',
+    Newer = 'newer',
+    Larger = 'larger',
+    Identical = 'identical',
+}
+
+export enum OpOutcome {
+    Created = 'created',
+    Renamed = 'renamed',
+    Skipped = 'skipped',
+    Overwritten = 'overwritten',
+    Identical = 'identical',
+}
+
+export enum OpStatus {
+    Staged = 'staged',
+    Queued = 'queued',
+    Running = 'running',
+    Succeeded = 'succeeded',
+    Failed = 'failed',
+    Canceled = 'canceled',
+    Reverted = 'reverted',
+    Dismissed = 'dismissed',
 }
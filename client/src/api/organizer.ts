@@ -1,5 +1,6 @@
 import { Config } from '@/api/config';
 import { File } from '@/api/file';
+import { Operation } from '@/api/operation';
 
 /**
  * OrganizerStatus represents the status of the organizer.
@@ -9,4 +10,21 @@ export interface OrganizerStatus {
     currentFile: File;
     currentFileIndex: number;
     numFiles: number;
+    operations: OperationsStatus;
+    currentFileOperations: Operation[];
+    currentFileNotReady: string;
+    numAddedFiles: number;
+    numGoneFiles: number;
+}
+
+/**
+ * OperationsStatus represents the status of the operations submitted to the organizer.
+ */
+export interface OperationsStatus {
+    numStaged: number;
+    numQueued: number;
+    numRunning: number;
+    numSucceeded: number;
+    numFailed: number;
+    failed: Operation[];
 }
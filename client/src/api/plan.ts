@@ -0,0 +1,27 @@
+import { OpOutcome, OpType } from '@/api/operation';
+
+/**
+ * Plan represents the list of operations that the organizer would execute.
+ */
+export interface Plan {
+    steps: PlanStep[];
+}
+
+/**
+ * PlanStep represents an operation in a plan.
+ * predictedDstPath is the destination expected once name collisions
+ * with existing files or with previous steps are resolved,
+ * in which case collision is true.
+ * outcome is the expected outcome of the operation according to its collision policy;
+ * steps leaving the file untouched have an empty predictedDstPath.
+ */
+export interface PlanStep {
+    opId: number;
+    op: OpType;
+    srcPath: string;
+    dstPath: string;
+    predictedDstPath: string;
+    collision: boolean;
+    outcome: OpOutcome;
+    err: string;
+}
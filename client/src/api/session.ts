@@ -0,0 +1,20 @@
+import { Config } from '@/api/config';
+import { Operation } from '@/api/operation';
+
+/**
+ * Session represents the persisted state of an organizing session.
+ */
+export interface Session {
+    config: Config;
+    decisions: SessionDecision[];
+    currentFilePath: string;
+}
+
+/**
+ * SessionDecision represents a decision taken on a file during a session.
+ */
+export interface SessionDecision {
+    filePath: string;
+    skipped: boolean;
+    ops: Operation[];
+}
@@ -1,7 +1,9 @@
 import { organizerAPI } from '@/api/api';
 import { Config, DstDir } from '@/api/config';
 import { File } from '@/api/file';
-import { OrganizerStatus } from '@/api/organizer';
+import { HotkeyEvent } from '@/api/hotkey';
+import { Operation } from '@/api/operation';
+import { OperationsStatus, OrganizerStatus } from '@/api/organizer';
 import to from 'await-to-js';
 import { namespace } from 'vuex-class';
 import { Action, Module, Mutation, VuexModule } from 'vuex-module-decorators';
@@ -31,6 +33,40 @@ export default class Organizer extends VuexModule {
      */
     public numFiles: number = 0;
 
+    /**
+     * currentFileNotReady explains why the current file
+     * cannot be sent to a destination directory yet, if not empty.
+     */
+    public currentFileNotReady: string = '';
+
+    /**
+     * numAddedFiles represents the number of files added to the source directory
+     * while organizing.
+     */
+    public numAddedFiles: number = 0;
+
+    /**
+     * numGoneFiles represents the number of files vanished from the source directory.
+     */
+    public numGoneFiles: number = 0;
+
+    /**
+     * operations represents the status of the operations submitted to the organizer.
+     */
+    public operations: OperationsStatus | null = null;
+
+    /**
+     * currentFileOperations represents the operations already submitted
+     * for the current file.
+     */
+    public currentFileOperations: Operation[] = [];
+
+    /**
+     * unfinishedOperations represents the operations submitted in previous sessions
+     * that were never completed.
+     */
+    public unfinishedOperations: Operation[] = [];
+
     /**
      * hasCurrentFile returns true if the organizer is active
      * and has a file to display.
@@ -46,6 +82,13 @@ export default class Organizer extends VuexModule {
         return this.config !== null;
     }
 
+    /**
+     * failedOperations returns the list of failed operations.
+     */
+    public get failedOperations(): Operation[] {
+        return this.operations ? this.operations.failed : [];
+    }
+
     /**
      * dstDirs returns the list of destination directories.
      */
@@ -73,6 +116,27 @@ export default class Organizer extends VuexModule {
         }
     }
 
+    @Action({ commit: 'setStatus' })
+    public async resumeSession() {
+        const [err, status] = await to<OrganizerStatus, string>(
+            organizerAPI.resumeSession(),
+        );
+        if (err) {
+            console.error(err);
+            return null;
+        }
+
+        return status;
+    }
+
+    @Action
+    public async discardSession() {
+        const [err] = await to<void, string>(organizerAPI.discardSession());
+        if (err) {
+            console.error(err);
+        }
+    }
+
     @Action({ commit: 'setStatus' })
     public async loadConfig(config: Config) {
         const [err, status] = await to<OrganizerStatus, string>(
@@ -138,6 +202,198 @@ export default class Organizer extends VuexModule {
         return status;
     }
 
+    @Action({ commit: 'setStatus' })
+    public async handleHotkeyEvent(event: HotkeyEvent) {
+        const [err, status] = await to<OrganizerStatus, string>(
+            organizerAPI.handleHotkeyEvent(event),
+        );
+        if (err) {
+            console.error(err);
+            return null;
+        }
+
+        return status;
+    }
+
+    @Action({ commit: 'setStatus' })
+    public async handleHotkeys(hotkeys: string[]) {
+        const [err, status] = await to<OrganizerStatus, string>(
+            organizerAPI.handleHotkeys(hotkeys),
+        );
+        if (err) {
+            console.error(err);
+            return null;
+        }
+
+        return status;
+    }
+
+    @Action({ commit: 'setStatus' })
+    public async previousFile() {
+        const [err, status] = await to<OrganizerStatus, string>(
+            organizerAPI.previousFile(),
+        );
+        if (err) {
+            console.error(err);
+            return null;
+        }
+
+        return status;
+    }
+
+    @Action({ commit: 'setStatus' })
+    public async goToFile(index: number) {
+        const [err, status] = await to<OrganizerStatus, string>(
+            organizerAPI.goToFile(index),
+        );
+        if (err) {
+            console.error(err);
+            return null;
+        }
+
+        return status;
+    }
+
+    @Action({ commit: 'setStatus' })
+    public async goToFileByName(pattern: string) {
+        const [err, status] = await to<OrganizerStatus, string>(
+            organizerAPI.goToFileByName(pattern),
+        );
+        if (err) {
+            console.error(err);
+            return null;
+        }
+
+        return status;
+    }
+
+    @Action({ commit: 'setStatus' })
+    public async undo() {
+        const [err, status] = await to<OrganizerStatus, string>(
+            organizerAPI.undo(),
+        );
+        if (err) {
+            console.error(err);
+            return null;
+        }
+
+        return status;
+    }
+
+    @Action({ commit: 'setStatus' })
+    public async renameCurrentFile(newName: string) {
+        const [err, status] = await to<OrganizerStatus, string>(
+            organizerAPI.renameCurrentFile(newName),
+        );
+        if (err) {
+            console.error(err);
+            return null;
+        }
+
+        return status;
+    }
+
+    @Action({ commit: 'setStatus' })
+    public async commitDecisions() {
+        const [err, status] = await to<OrganizerStatus, string>(
+            organizerAPI.commitDecisions(),
+        );
+        if (err) {
+            console.error(err);
+            return null;
+        }
+
+        return status;
+    }
+
+    @Action({ commit: 'setStatus' })
+    public async retryOperation(payload: { id: number; dstDir: string }) {
+        const [err, status] = await to<OrganizerStatus, string>(
+            organizerAPI.retryOperation(payload.id, payload.dstDir),
+        );
+        if (err) {
+            console.error(err);
+            return null;
+        }
+
+        return status;
+    }
+
+    @Action({ commit: 'setStatus' })
+    public async retryAllFailed() {
+        const [err, status] = await to<OrganizerStatus, string>(
+            organizerAPI.retryAllFailed(),
+        );
+        if (err) {
+            console.error(err);
+            return null;
+        }
+
+        return status;
+    }
+
+    @Action({ commit: 'setStatus' })
+    public async dismissOperation(id: number) {
+        const [err, status] = await to<OrganizerStatus, string>(
+            organizerAPI.dismissOperation(id),
+        );
+        if (err) {
+            console.error(err);
+            return null;
+        }
+
+        return status;
+    }
+
+    @Action({ commit: 'setUnfinishedOperations' })
+    public async fetchUnfinishedOperations() {
+        const [err, ops] = await to<Operation[], string>(
+            organizerAPI.unfinishedOperations(),
+        );
+        if (err) {
+            console.error(err);
+            return null;
+        }
+
+        // No unfinished operations are returned as null.
+        return ops || [];
+    }
+
+    @Action({ commit: 'setStatus' })
+    public async replayUnfinished() {
+        const [err, status] = await to<OrganizerStatus, string>(
+            organizerAPI.replayUnfinished(),
+        );
+        if (err) {
+            console.error(err);
+            return null;
+        }
+
+        this.context.commit('setUnfinishedOperations', []);
+        return status;
+    }
+
+    @Action({ commit: 'setStatus' })
+    public async discardUnfinished() {
+        const [err, status] = await to<OrganizerStatus, string>(
+            organizerAPI.discardUnfinished(),
+        );
+        if (err) {
+            console.error(err);
+            return null;
+        }
+
+        this.context.commit('setUnfinishedOperations', []);
+        return status;
+    }
+
+    @Mutation
+    private setUnfinishedOperations(ops: Operation[] | null) {
+        if (ops) {
+            this.unfinishedOperations = ops;
+        }
+    }
+
     @Mutation
     private setStatus(status: OrganizerStatus | null) {
         if (status) {
@@ -145,6 +401,11 @@ export default class Organizer extends VuexModule {
             this.currentFile = status.currentFile;
             this.currentFileIndex = status.currentFileIndex;
             this.numFiles = status.numFiles;
+            this.currentFileNotReady = status.currentFileNotReady;
+            this.numAddedFiles = status.numAddedFiles;
+            this.numGoneFiles = status.numGoneFiles;
+            this.operations = status.operations;
+            this.currentFileOperations = status.currentFileOperations || [];
         }
     }
 }
@@ -1,3 +1,37 @@
 export const capitalize = (s: string) => {
     return s.charAt(0).toUpperCase() + s.slice(1);
 };
+
+// codeKeys maps the codes of the punctuation keys to their unshifted characters
+// on the US layout.
+const codeKeys: { [code: string]: string } = {
+    Backquote: '`',
+    Minus: '-',
+    Equal: '=',
+    BracketLeft: '[',
+    BracketRight: ']',
+    Backslash: '\\',
+    Semicolon: ';',
+    Quote: "'",
+    Comma: ',',
+    Period: '.',
+    Slash: '/',
+};
+
+/**
+ * unshiftedKey returns the key of the given event as if shift was not held,
+ * for example "a" for "A" or "1" for "!".
+ * Letters follow the keyboard layout, while digits and punctuation
+ * are derived from the physical key code, like Digit1.
+ */
+export const unshiftedKey = (e: KeyboardEvent) => {
+    const isLetter = e.key.toLowerCase() !== e.key.toUpperCase();
+    if (isLetter) {
+        return e.key.toLowerCase();
+    }
+    const digit = /^(?:Digit|Numpad)([0-9])$/.exec(e.code);
+    if (digit) {
+        return digit[1];
+    }
+    return codeKeys[e.code] || e.key;
+};
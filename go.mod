@@ -4,6 +4,7 @@ go 1.13
 
 require (
 	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/fsnotify/fsnotify v1.4.9
 	github.com/gammazero/workerpool v0.0.0-20190608213748-0ed5e40ec55e
 	github.com/gen2brain/dlgs v0.0.0-20190708095831-3854608588f7
 	github.com/gopherjs/gopherjs v0.0.0-20191106031601-ce3c9ade29de // indirect
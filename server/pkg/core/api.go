@@ -18,11 +18,30 @@ type OrganizerAPI interface {
 	// RestoreConfig TODO:
 	RestoreConfig() (*Config, error)
 
+	// ResumeSession restores the latest organizing session, starting the organizer.
+	// The session's configuration must still be valid.
+	// Files already moved away from the source directory are no longer organized,
+	// while the other files already decided are placed before the current file.
+	ResumeSession() (*OrganizerStatus, error)
+
+	// LatestSession returns the latest organizing session, which can be resumed
+	// with ResumeSession, or nil if there is none.
+	// The latest session is not overwritten by LoadConfig until it is resumed
+	// or discarded with DiscardSession.
+	LatestSession() (*Session, error)
+
+	// DiscardSession removes the latest organizing session,
+	// which is otherwise kept until it is resumed.
+	DiscardSession() error
+
 	// LoadConfig loads the given configuration, which must be valid, starting the organizer.
 	LoadConfig(config *Config) (*OrganizerStatus, error)
 
 	// DropConfigWait removes the current configuration, if any, stopping the organizer.
 	// All submitted operations, pending or in progress, are completed.
+	// Operations staged in deferred mode are discarded unless committed first with CommitDecisions.
+	// The returned status reports the operations of the removed configuration,
+	// so that the operations failed while completing them are not lost.
 	DropConfigWait() (*OrganizerStatus, error)
 
 	// DropConfig removes the current configuration, if any, stopping the organizer.
@@ -36,6 +55,102 @@ type OrganizerAPI interface {
 	// If the hotkey is the space character, the organizer advances to the next file.
 	// If the hotkey is associated to a destination directory, the organizer creates
 	// a file operation for the current file and then advances to the next file.
+	// If the current file was already sent to a destination directory,
+	// the hotkey associated to a destination directory is ignored.
+	// In deferred and dry run modes, operations are only staged and the decision
+	// on a file can be changed by revisiting it, until CommitDecisions is called.
+	// If the hotkey is pressed with the shift modifier, for example "Shift+a",
+	// and no destination directory is associated to the modified hotkey,
+	// the operation type is inverted, from move to copy or from copy to move,
+	// for the current file only.
 	// If the hotkey is unrecognized, the organizer does nothing.
 	HandleHotkey(hotkey string) (*OrganizerStatus, error)
+
+	// HandleHotkeyEvent handles the action corresponding to the pressed hotkey
+	// and modifier keys, like HandleHotkey.
+	// If the event reports the typed character, for example "A" or "!",
+	// a destination directory associated to that character takes precedence
+	// over inverting the operation type of the unmodified key.
+	HandleHotkeyEvent(event *HotkeyEvent) (*OrganizerStatus, error)
+
+	// HandleHotkeys sends the current file to all the destination directories
+	// associated to the given hotkeys with a single decision, and then advances
+	// to the next file.
+	// The operation for each destination directory is determined as in HandleHotkey,
+	// except that the file is copied instead of moved to all the destination directories
+	// but the last one; the last operation is executed only after all the others have finished.
+	// If a hotkey is unrecognized or if two hotkeys are associated
+	// to the same destination directory, the organizer does nothing.
+	HandleHotkeys(hotkeys []string) (*OrganizerStatus, error)
+
+	// PreviousFile goes back to the previous file, if any.
+	PreviousFile() (*OrganizerStatus, error)
+
+	// GoToFile makes the file at the given zero-based index the current file.
+	GoToFile(index int) (*OrganizerStatus, error)
+
+	// GoToFileByName makes the next file whose name matches the given
+	// shell pattern, as defined by filepath.Match, the current file.
+	// The search starts after the current file and wraps around.
+	GoToFileByName(pattern string) (*OrganizerStatus, error)
+
+	// RenameCurrentFile renames the current file, in its directory, to the given name.
+	// The new name must be a valid file name not used by another file.
+	// Files with operations already submitted cannot be renamed.
+	RenameCurrentFile(newName string) (*OrganizerStatus, error)
+
+	// CommitDecisions submits to the worker pool all the operations
+	// staged in deferred mode.
+	// Operations staged in dry run mode cannot be committed.
+	CommitDecisions() (*OrganizerStatus, error)
+
+	// ExecutionPlan returns the plan of the operations staged or pending,
+	// predicting the name collisions at their destinations.
+	// In dry run mode, the plan contains all the operations decided so far.
+	ExecutionPlan() (*Plan, error)
+
+	// ExportPlanScript returns the execution plan as a POSIX shell script.
+	ExportPlanScript() (string, error)
+
+	// ExportPlanCSV returns the execution plan in CSV format, with a src,dst,op header.
+	ExportPlanCSV() (string, error)
+
+	// ImportPlanCSV submits to the worker pool the operations listed in the given plan,
+	// in the format returned by ExportPlanCSV.
+	// Imported operations are tracked like the other operations
+	// and can be retried if they fail.
+	ImportPlanCSV(plan string) (*OrganizerStatus, error)
+
+	// Undo reverts the last decision taken with HandleHotkey, going back to the previous file.
+	// If the decision submitted an operation, the operation is canceled if still staged or pending
+	// or reverted if already executed: moved files are moved back to their
+	// original path, trashed files are restored from the trash and copies and links are removed.
+	// Staged operations replaced by the decision are staged again.
+	// Decisions whose operations were submitted in a previous session cannot be undone.
+	Undo() (*OrganizerStatus, error)
+
+	// RetryOperation submits again the failed operation with the given ID.
+	// If dstDir is not empty, the operation's destination is moved to that directory,
+	// keeping the subfolders and the name rendered by the destination's templates;
+	// trash operations cannot be moved.
+	RetryOperation(id int64, dstDir string) (*OrganizerStatus, error)
+
+	// RetryAllFailed submits again all the failed operations.
+	RetryAllFailed() (*OrganizerStatus, error)
+
+	// DismissOperation marks the failed operation with the given ID as dismissed,
+	// removing it from the list of failed operations.
+	DismissOperation(id int64) (*OrganizerStatus, error)
+
+	// UnfinishedOperations returns the operations submitted in previous sessions
+	// that were never completed, for example because the application was closed
+	// before executing them or because it crashed.
+	UnfinishedOperations() ([]*Operation, error)
+
+	// ReplayUnfinished submits again, using the current configuration,
+	// the operations returned by UnfinishedOperations.
+	ReplayUnfinished() (*OrganizerStatus, error)
+
+	// DiscardUnfinished forgets the operations returned by UnfinishedOperations.
+	DiscardUnfinished() (*OrganizerStatus, error)
 }
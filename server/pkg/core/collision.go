@@ -0,0 +1,154 @@
+package core
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"io"
+	"os"
+)
+
+// CollisionPolicy enum type.
+type CollisionPolicy string
+
+// CollisionPolicy enum values.
+const (
+	CollisionPolicyRename    CollisionPolicy = "rename"
+	CollisionPolicySkip      CollisionPolicy = "skip"
+	CollisionPolicyOverwrite CollisionPolicy = "overwrite"
+	CollisionPolicyNewer     CollisionPolicy = "newer"
+	CollisionPolicyLarger    CollisionPolicy = "larger"
+	CollisionPolicyIdentical CollisionPolicy = "identical"
+)
+
+// IsValid returns true if the CollisionPolicy value belongs to the enum.
+func (p CollisionPolicy) IsValid() bool {
+	valid := map[CollisionPolicy]bool{
+		CollisionPolicyRename:    true,
+		CollisionPolicySkip:      true,
+		CollisionPolicyOverwrite: true,
+		CollisionPolicyNewer:     true,
+		CollisionPolicyLarger:    true,
+		CollisionPolicyIdentical: true,
+	}
+	return valid[p]
+}
+
+// OpOutcome enum type.
+type OpOutcome string
+
+// OpOutcome enum values.
+const (
+	// OpOutcomeCreated means that the destination did not exist.
+	OpOutcomeCreated OpOutcome = "created"
+	// OpOutcomeRenamed means that the file was sent to a destination
+	// with a numeric suffix, to avoid a collision.
+	OpOutcomeRenamed OpOutcome = "renamed"
+	// OpOutcomeSkipped means that the existing destination was kept
+	// and the file was left untouched.
+	OpOutcomeSkipped OpOutcome = "skipped"
+	// OpOutcomeOverwritten means that the existing destination was replaced.
+	OpOutcomeOverwritten OpOutcome = "overwritten"
+	// OpOutcomeIdentical means that the existing destination has the same content
+	// of the file, which was left untouched.
+	OpOutcomeIdentical OpOutcome = "identical"
+)
+
+// isSkipped returns true if the outcome leaves the file untouched.
+func (o OpOutcome) isSkipped() bool {
+	return o == OpOutcomeSkipped || o == OpOutcomeIdentical
+}
+
+// collisionOutcome returns how the given operation must be executed
+// according to its collision policy and to the file found at its destination, if any.
+// OpOutcomeCreated is returned if the destination does not exist,
+// OpOutcomeRenamed if the file must be sent to another destination.
+func collisionOutcome(op *Operation) (OpOutcome, error) {
+	_, err := os.Stat(op.DstPath)
+	if os.IsNotExist(err) {
+		return OpOutcomeCreated, nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return resolveCollision(op.CollisionPolicy, op.SrcPath, op.DstPath)
+}
+
+// resolveCollision returns the outcome of sending the file at srcPath
+// to a destination already holding the file at existingPath,
+// according to the given collision policy.
+func resolveCollision(policy CollisionPolicy, srcPath, existingPath string) (OpOutcome, error) {
+	switch policy {
+	case CollisionPolicySkip:
+		return OpOutcomeSkipped, nil
+	case CollisionPolicyOverwrite:
+		return OpOutcomeOverwritten, nil
+	case CollisionPolicyNewer, CollisionPolicyLarger:
+		srcInfo, err := os.Stat(srcPath)
+		if err != nil {
+			return "", err
+		}
+		existingInfo, err := os.Stat(existingPath)
+		if err != nil {
+			return "", err
+		}
+		replace := srcInfo.ModTime().After(existingInfo.ModTime())
+		if policy == CollisionPolicyLarger {
+			replace = srcInfo.Size() > existingInfo.Size()
+		}
+		if replace {
+			return OpOutcomeOverwritten, nil
+		}
+		return OpOutcomeSkipped, nil
+	case CollisionPolicyIdentical:
+		identical, err := sameContent(srcPath, existingPath)
+		if err != nil {
+			return "", err
+		}
+		if identical {
+			return OpOutcomeIdentical, nil
+		}
+		return OpOutcomeRenamed, nil
+	default:
+		return OpOutcomeRenamed, nil
+	}
+}
+
+// sameContent returns true if the files at the given paths have the same content.
+func sameContent(path1, path2 string) (bool, error) {
+	info1, err := os.Stat(path1)
+	if err != nil {
+		return false, err
+	}
+	info2, err := os.Stat(path2)
+	if err != nil {
+		return false, err
+	}
+	if info1.Size() != info2.Size() {
+		return false, nil
+	}
+
+	hash1, err := fileHash(path1)
+	if err != nil {
+		return false, err
+	}
+	hash2, err := fileHash(path2)
+	if err != nil {
+		return false, err
+	}
+	return bytes.Equal(hash1, hash2), nil
+}
+
+// fileHash returns the SHA-256 hash of the content of the file at the given path.
+func fileHash(path string) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return nil, err
+	}
+	return h.Sum(nil), nil
+}
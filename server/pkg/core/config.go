@@ -9,26 +9,79 @@ type Config struct {
 	Ops  *ConfigOps `json:"ops"`
 }
 
-// ConfigSrc contains the configuration options for the source directory.
+// ConfigSrc contains the configuration options for the source directories.
+// Dir, IncludeSubdirs and DefaultOpType describe the main source directory,
+// while ExtraDirs lists other source directories whose files are merged
+// in the same queue; in lexical path order, they follow the files of the main one.
+// Filters, Sort and CheckStable apply to the files of all the source directories.
+// If Filters is nil, all the files in the source directories are organized.
+// Sort is the order in which files are organized, by default the lexical path order;
+// SortSeed is the seed of the random order; if zero, a seed is generated
+// for each session and kept when the session is resumed.
+// If CheckStable is true, partial downloads and files whose size and modification time
+// were not observed unchanged for StableSeconds seconds, by default 2,
+// cannot be organized until they are complete.
 type ConfigSrc struct {
+	Dir            string      `json:"dir"`
+	IncludeSubdirs bool        `json:"includeSubdirs"`
+	DefaultOpType  OpType      `json:"defaultOpType"`
+	Filters        *SrcFilters `json:"filters"`
+	Sort           SrcSort     `json:"sort"`
+	SortSeed       int64       `json:"sortSeed"`
+	CheckStable    bool        `json:"checkStable"`
+	StableSeconds  int         `json:"stableSeconds"`
+	ExtraDirs      []*SrcDir   `json:"extraDirs"`
+}
+
+// SrcDir represents a source directory.
+type SrcDir struct {
 	Dir            string `json:"dir"`
 	IncludeSubdirs bool   `json:"includeSubdirs"`
 	DefaultOpType  OpType `json:"defaultOpType"`
 }
 
+// srcDirs returns all the source directories, indexed by their source ID,
+// starting from the main one with ID 0.
+func (s *ConfigSrc) srcDirs() []*SrcDir {
+	dirs := []*SrcDir{{
+		Dir:            s.Dir,
+		IncludeSubdirs: s.IncludeSubdirs,
+		DefaultOpType:  s.DefaultOpType,
+	}}
+	return append(dirs, s.ExtraDirs...)
+}
+
 // ConfigDst contains the configuration options for the destination directories.
 type ConfigDst struct {
 	Dirs []*DstDir `json:"dirs"`
 }
 
 // DstDir represents a destination directory.
+// If OpType is empty, the source's DefaultOpType is used.
+// If OpType is OpTypeTrash, files are sent to the user's trash and Dir is ignored.
+// If Rename is not empty, files are renamed with the given template,
+// for example "{mtime:2006-01-02}_{name}_{counter:3}.{ext}"; see parseTemplate.
+// If Subpath is not empty, files are sent to the subdirectories of Dir
+// given by the template, for example "{mtime:2006}/{mtime:01}",
+// which are created as needed.
 type DstDir struct {
-	Hotkey string `json:"hotkey"`
-	Dir    string `json:"dir"`
+	Hotkey  string `json:"hotkey"`
+	Dir     string `json:"dir"`
+	OpType  OpType `json:"op"`
+	Rename  string `json:"rename"`
+	Subpath string `json:"subpath"`
+}
+
+func (d *DstDir) isTrash() bool {
+	return d.OpType == OpTypeTrash
 }
 
 // ConfigOps contains the configuration options specifying how operations should be executed.
+// If CollisionPolicy is empty, files are renamed as with CollisionPolicyRename.
 type ConfigOps struct {
-	NumWorkers int `json:"numWorkers"`
-	MaxTries   int `json:"maxTries"`
+	NumWorkers      int             `json:"numWorkers"`
+	MaxTries        int             `json:"maxTries"`
+	Deferred        bool            `json:"deferred"`
+	DryRun          bool            `json:"dryRun"`
+	CollisionPolicy CollisionPolicy `json:"collisionPolicy"`
 }
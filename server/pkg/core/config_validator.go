@@ -3,6 +3,7 @@ package core
 import (
 	"encoding/json"
 	"fmt"
+	"path/filepath"
 	"strings"
 	"unicode/utf8"
 
@@ -19,21 +20,38 @@ const (
 	ErrConfigNameEmpty = "name is empty"
 
 	// ConfigSrc keys
-	ErrKeySrc              = "config.src"
-	ErrKeySrcDir           = "config.src.dir"
-	ErrKeySrcDefaultOpType = "config.src.defaultOpType"
+	ErrKeySrc               = "config.src"
+	ErrKeySrcDir            = "config.src.dir"
+	ErrKeySrcDefaultOpType  = "config.src.defaultOpType"
+	ErrKeySrcFiltersInclude = "config.src.filters.include"
+	ErrKeySrcFiltersExclude = "config.src.filters.exclude"
+	ErrKeySrcFiltersSize    = "config.src.filters.size"
+	ErrKeySrcFiltersMtime   = "config.src.filters.mtime"
+	ErrKeySrcSort           = "config.src.sort"
+	ErrKeySrcStableSeconds  = "config.src.stableSeconds"
+	ErrKeySrcExtraDirPath   = "config.src.extraDirs.%v.dir"
+	ErrKeySrcExtraDirOpType = "config.src.extraDirs.%v.defaultOpType"
 	// ConfigSrc errors
-	ErrSrcNil                   = "no configuration found"
-	ErrSrcDirPathEmpty          = "path is empty"
-	ErrSrcDirPathNotValid       = "path is not valid"
-	ErrSrcDirEmpty              = "directory contains no files"
-	ErrSrcDefaultOpTypeNotValid = "default operation type is not valid"
+	ErrSrcNil                    = "no configuration found"
+	ErrSrcDirPathEmpty           = "path is empty"
+	ErrSrcDirPathNotValid        = "path is not valid"
+	ErrSrcDirEmpty               = "directory contains no files"
+	ErrSrcDefaultOpTypeNotValid  = "default operation type is not valid"
+	ErrSrcFiltersPatternNotValid = "pattern is not valid"
+	ErrSrcFiltersSizeNotValid    = "size range is not valid"
+	ErrSrcFiltersMtimeNotValid   = "date range is not valid"
+	ErrSrcSortNotValid           = "sort order is not valid"
+	ErrSrcStableSecondsNegative  = "interval is negative"
+	ErrSrcDirPathOverlapping     = "path overlaps another source directory"
 
 	// ConfigDst keys
-	ErrKeyDst          = "config.dst"
-	ErrKeyDstDirs      = "config.dst.dirs"
-	ErrKeyDstDirHotkey = "config.dst.dirs.%v.hotkey"
-	ErrKeyDstDirPath   = "config.dst.dirs.%v.dir"
+	ErrKeyDst           = "config.dst"
+	ErrKeyDstDirs       = "config.dst.dirs"
+	ErrKeyDstDirHotkey  = "config.dst.dirs.%v.hotkey"
+	ErrKeyDstDirPath    = "config.dst.dirs.%v.dir"
+	ErrKeyDstDirOpType  = "config.dst.dirs.%v.op"
+	ErrKeyDstDirRename  = "config.dst.dirs.%v.rename"
+	ErrKeyDstDirSubpath = "config.dst.dirs.%v.subpath"
 	// ConfigDst errors
 	ErrDstNil                           = "no configuration found"
 	ErrDstDirsEmpty                     = "no destination directories"
@@ -42,19 +60,25 @@ const (
 	ErrDstDirHotkeyDuplicate            = "hotkey is a duplicate"
 	ErrDstDirPathEmpty                  = "path is empty"
 	ErrDstDirPathNotValid               = "path is not valid"
-	ErrDstDirPathNotDifferentFromSrcDir = "path points to the source directory"
-	ErrDstDirPathChildOfSrcDir          = "path is inside the source directory"
+	ErrDstDirPathNotDifferentFromSrcDir = "path points to a source directory"
+	ErrDstDirPathChildOfSrcDir          = "path is inside a source directory"
+	ErrDstDirOpTypeNotValid             = "operation type is not valid"
+	ErrDstDirPathHardlinkCrossDevice    = "path is on another filesystem, hardlinks are not possible"
+	ErrDstDirRenameNotValid             = "rename template is not valid"
+	ErrDstDirSubpathNotValid            = "subpath template is not valid"
 
 	// ConfigOps keys
-	ErrKeyOps           = "config.ops"
-	ErrKeyOpsNumWorkers = "config.ops.numWorkers"
-	ErrKeyOpsMaxTries   = "config.ops.maxTries"
+	ErrKeyOps                = "config.ops"
+	ErrKeyOpsNumWorkers      = "config.ops.numWorkers"
+	ErrKeyOpsMaxTries        = "config.ops.maxTries"
+	ErrKeyOpsCollisionPolicy = "config.ops.collisionPolicy"
 	// ConfigOps errors
 	ErrOpsNil                        = "no configuration found"
 	ErrOpsNumWorkersNotAtLeastOne    = "number of workers is less than one"
 	ErrOpsNumWorkersMoreThanFive     = "number of workers is more than five"
 	ErrOpsMaxTriesNotAtLeastOne      = "number of maximum operation tries is less than one"
 	ErrOpsMaxTriesMoreThanOneMillion = "number of maximum operation tries is more than one million"
+	ErrOpsCollisionPolicyNotValid    = "collision policy is not valid"
 )
 
 // ConfigValidator represents the validator for configurations.
@@ -120,8 +144,18 @@ func (v *configValidator) validationPredicates() []func() bool {
 		// ConfigSrc
 		v.isSrcDirPathNotEmpty,
 		v.isSrcDirPathValid,
+		v.areSrcFiltersPatternsAllValid,
+		v.isSrcFiltersSizeRangeValid,
+		v.isSrcFiltersMtimeRangeValid,
 		v.isSrcDirNotEmpty,
 		v.isSrcDefaultOpTypeValid,
+		v.isSrcSortValid,
+		v.isSrcStableSecondsNotNegative,
+		v.areSrcExtraDirsPathsAllNotEmpty,
+		v.areSrcExtraDirsPathsAllValid,
+		v.areSrcExtraDirsPathsAllNotOverlapping,
+		v.areSrcExtraDirsAllNotEmpty,
+		v.areSrcExtraDirsDefaultOpTypesAllValid,
 		// ConfigDst
 		v.areDstDirsNotEmpty,
 		v.areDstDirsHotkeysAllNotEmpty,
@@ -129,13 +163,18 @@ func (v *configValidator) validationPredicates() []func() bool {
 		v.areDstDirsHotkeysAllDistinct,
 		v.areDstDirsPathsAllNotEmpty,
 		v.areDstDirsPathsAllValid,
-		v.areDstDirsPathsAllDifferentFromSrcDir,
-		v.areDstDirsPathsAllNotChildrenOfSrcDir,
+		v.areDstDirsPathsAllDifferentFromSrcDirs,
+		v.areDstDirsPathsAllNotChildrenOfSrcDirs,
+		v.areDstDirsOpTypesAllValid,
+		v.areDstDirsHardlinksAllOnSrcFilesystems,
+		v.areDstDirsRenameTemplatesAllValid,
+		v.areDstDirsSubpathTemplatesAllValid,
 		// ConfigOps
 		v.isOpsNumWorkersAtLeastOne,
 		v.isOpsNumWorkersLessThanFive,
 		v.isOpsMaxTriesAtLeastOne,
 		v.isOpsMaxTriesLessThanOneMillion,
+		v.isOpsCollisionPolicyValid,
 	}
 }
 
@@ -181,18 +220,128 @@ func (v *configValidator) isSrcDirPathValid() bool {
 	return ok
 }
 
+func (v *configValidator) areSrcFiltersPatternsAllValid() bool {
+	filters := v.config.Src.Filters
+	if filters == nil {
+		return true
+	}
+	includeOk := areValidPatterns(filters.Include)
+	v.addErrIf(!includeOk, ErrKeySrcFiltersInclude, ErrSrcFiltersPatternNotValid)
+	excludeOk := areValidPatterns(filters.Exclude)
+	v.addErrIf(!excludeOk, ErrKeySrcFiltersExclude, ErrSrcFiltersPatternNotValid)
+	return includeOk && excludeOk
+}
+
+func (v *configValidator) isSrcFiltersSizeRangeValid() bool {
+	filters := v.config.Src.Filters
+	if filters == nil {
+		return true
+	}
+	ok := filters.MinSize >= 0 && filters.MaxSize >= 0 &&
+		(filters.MaxSize == 0 || filters.MinSize <= filters.MaxSize)
+	v.addErrIf(!ok, ErrKeySrcFiltersSize, ErrSrcFiltersSizeNotValid)
+	return ok
+}
+
+func (v *configValidator) isSrcFiltersMtimeRangeValid() bool {
+	filters := v.config.Src.Filters
+	if filters == nil {
+		return true
+	}
+	from, to, err := filters.mtimeRange()
+	ok := err == nil && (from.IsZero() || to.IsZero() || from.Before(to))
+	v.addErrIf(!ok, ErrKeySrcFiltersMtime, ErrSrcFiltersMtimeNotValid)
+	return ok
+}
+
 func (v *configValidator) isSrcDirNotEmpty() bool {
-	ok := isNotEmptyDir(v.config.Src.Dir, v.config.Src.IncludeSubdirs)
+	src := v.config.Src
+	ok := isNotEmptyDir(src.Dir, src.IncludeSubdirs, src.Filters)
 	v.addErrIf(!ok, ErrKeySrcDir, ErrSrcDirEmpty)
 	return ok
 }
 
 func (v *configValidator) isSrcDefaultOpTypeValid() bool {
-	ok := v.config.Src.DefaultOpType.IsValid()
+	opType := v.config.Src.DefaultOpType
+	ok := opType.IsValid() && opType != OpTypeTrash
 	v.addErrIf(!ok, ErrKeySrcDefaultOpType, ErrSrcDefaultOpTypeNotValid)
 	return ok
 }
 
+func (v *configValidator) isSrcSortValid() bool {
+	ok := v.config.Src.Sort.IsValid()
+	v.addErrIf(!ok, ErrKeySrcSort, ErrSrcSortNotValid)
+	return ok
+}
+
+func (v *configValidator) isSrcStableSecondsNotNegative() bool {
+	ok := v.config.Src.StableSeconds >= 0
+	v.addErrIf(!ok, ErrKeySrcStableSeconds, ErrSrcStableSecondsNegative)
+	return ok
+}
+
+func (v *configValidator) areSrcExtraDirsPathsAllNotEmpty() bool {
+	allOk := true
+	for i, d := range v.config.Src.ExtraDirs {
+		ok := d != nil && isNotEmptyString(d.Dir)
+		v.addErrWithIndexIf(!ok, ErrKeySrcExtraDirPath, i, ErrSrcDirPathEmpty)
+		allOk = allOk && ok
+	}
+	return allOk
+}
+
+func (v *configValidator) areSrcExtraDirsPathsAllValid() bool {
+	allOk := true
+	for i, d := range v.config.Src.ExtraDirs {
+		ok := isDir(d.Dir)
+		v.addErrWithIndexIf(!ok, ErrKeySrcExtraDirPath, i, ErrSrcDirPathNotValid)
+		allOk = allOk && ok
+	}
+	return allOk
+}
+
+// areSrcExtraDirsPathsAllNotOverlapping checks that every extra source directory
+// differs from the previous source directories and that it is not inside,
+// nor contains, a previous source directory including its subdirectories,
+// so that no file belongs to more than one source.
+func (v *configValidator) areSrcExtraDirsPathsAllNotOverlapping() bool {
+	allOk := true
+	srcDirs := v.config.Src.srcDirs()
+	for i, d := range v.config.Src.ExtraDirs {
+		ok := true
+		// The extra directory at index i has source ID i+1.
+		for _, prev := range srcDirs[:i+1] {
+			ok = ok && areNotSameDir(d.Dir, prev.Dir) &&
+				(!prev.IncludeSubdirs || isNotChildDirOf(d.Dir, prev.Dir)) &&
+				(!d.IncludeSubdirs || isNotChildDirOf(prev.Dir, d.Dir))
+		}
+		v.addErrWithIndexIf(!ok, ErrKeySrcExtraDirPath, i, ErrSrcDirPathOverlapping)
+		allOk = allOk && ok
+	}
+	return allOk
+}
+
+func (v *configValidator) areSrcExtraDirsAllNotEmpty() bool {
+	allOk := true
+	filters := v.config.Src.Filters
+	for i, d := range v.config.Src.ExtraDirs {
+		ok := isNotEmptyDir(d.Dir, d.IncludeSubdirs, filters)
+		v.addErrWithIndexIf(!ok, ErrKeySrcExtraDirPath, i, ErrSrcDirEmpty)
+		allOk = allOk && ok
+	}
+	return allOk
+}
+
+func (v *configValidator) areSrcExtraDirsDefaultOpTypesAllValid() bool {
+	allOk := true
+	for i, d := range v.config.Src.ExtraDirs {
+		ok := d.DefaultOpType.IsValid() && d.DefaultOpType != OpTypeTrash
+		v.addErrWithIndexIf(!ok, ErrKeySrcExtraDirOpType, i, ErrSrcDefaultOpTypeNotValid)
+		allOk = allOk && ok
+	}
+	return allOk
+}
+
 func (v *configValidator) areDstDirsNotEmpty() bool {
 	ok := len(v.config.Dst.Dirs) > 0
 	v.addErrIf(!ok, ErrKeyDstDirs, ErrDstDirsEmpty)
@@ -214,7 +363,7 @@ func (v *configValidator) areDstDirsHotkeysAllOneRune() bool {
 	allOk := true
 	dirs := v.config.Dst.Dirs
 	for i, d := range dirs {
-		ok := utf8.RuneCountInString(d.Hotkey) == 1
+		ok := utf8.RuneCountInString(parseHotkey(d.Hotkey).Key) == 1
 		v.addErrWithIndexIf(!ok, ErrKeyDstDirHotkey, i, ErrDstDirHotkeyNotOneRune)
 		allOk = allOk && ok
 	}
@@ -226,8 +375,9 @@ func (v *configValidator) areDstDirsHotkeysAllDistinct() bool {
 	dirs := v.config.Dst.Dirs
 	seen := make(map[string]int)
 	for i, d := range dirs {
-		seen[d.Hotkey]++
-		ok := seen[d.Hotkey] == 1
+		hotkey := parseHotkey(d.Hotkey).String()
+		seen[hotkey]++
+		ok := seen[hotkey] == 1
 		v.addErrWithIndexIf(!ok, ErrKeyDstDirHotkey, i, ErrDstDirHotkeyDuplicate)
 		allOk = allOk && ok
 	}
@@ -238,6 +388,9 @@ func (v *configValidator) areDstDirsPathsAllNotEmpty() bool {
 	allOk := true
 	dirs := v.config.Dst.Dirs
 	for i, d := range dirs {
+		if d.isTrash() {
+			continue
+		}
 		ok := isNotEmptyString(d.Dir)
 		v.addErrWithIndexIf(!ok, ErrKeyDstDirPath, i, ErrDstDirPathEmpty)
 		allOk = allOk && ok
@@ -249,6 +402,9 @@ func (v *configValidator) areDstDirsPathsAllValid() bool {
 	allOk := true
 	dirs := v.config.Dst.Dirs
 	for i, d := range dirs {
+		if d.isTrash() {
+			continue
+		}
 		ok := isDir(d.Dir)
 		v.addErrWithIndexIf(!ok, ErrKeyDstDirPath, i, ErrDstDirPathNotValid)
 		allOk = allOk && ok
@@ -256,30 +412,98 @@ func (v *configValidator) areDstDirsPathsAllValid() bool {
 	return allOk
 }
 
-func (v *configValidator) areDstDirsPathsAllDifferentFromSrcDir() bool {
+func (v *configValidator) areDstDirsPathsAllDifferentFromSrcDirs() bool {
 	allOk := true
-	srcDir := v.config.Src.Dir
+	srcDirs := v.config.Src.srcDirs()
 	dirs := v.config.Dst.Dirs
 	for i, d := range dirs {
-		ok := areNotSameDir(d.Dir, srcDir)
+		if d.isTrash() {
+			continue
+		}
+		ok := true
+		for _, src := range srcDirs {
+			ok = ok && areNotSameDir(d.Dir, src.Dir)
+		}
 		v.addErrWithIndexIf(!ok, ErrKeyDstDirPath, i, ErrDstDirPathNotDifferentFromSrcDir)
 		allOk = allOk && ok
 	}
 	return allOk
 }
 
-func (v *configValidator) areDstDirsPathsAllNotChildrenOfSrcDir() bool {
+func (v *configValidator) areDstDirsPathsAllNotChildrenOfSrcDirs() bool {
 	allOk := true
-	srcDir := v.config.Src.Dir
+	srcDirs := v.config.Src.srcDirs()
 	dirs := v.config.Dst.Dirs
 	for i, d := range dirs {
-		ok := isNotChildDirOf(d.Dir, srcDir)
+		if d.isTrash() {
+			continue
+		}
+		ok := true
+		for _, src := range srcDirs {
+			ok = ok && isNotChildDirOf(d.Dir, src.Dir)
+		}
 		v.addErrWithIndexIf(!ok, ErrKeyDstDirPath, i, ErrDstDirPathChildOfSrcDir)
 		allOk = allOk && ok
 	}
 	return allOk
 }
 
+func (v *configValidator) areDstDirsOpTypesAllValid() bool {
+	allOk := true
+	dirs := v.config.Dst.Dirs
+	for i, d := range dirs {
+		ok := d.OpType == "" || d.OpType.IsValid()
+		v.addErrWithIndexIf(!ok, ErrKeyDstDirOpType, i, ErrDstDirOpTypeNotValid)
+		allOk = allOk && ok
+	}
+	return allOk
+}
+
+func (v *configValidator) areDstDirsHardlinksAllOnSrcFilesystems() bool {
+	allOk := true
+	srcDirs := v.config.Src.srcDirs()
+	dirs := v.config.Dst.Dirs
+	for i, d := range dirs {
+		ok := true
+		for _, src := range srcDirs {
+			opType := d.OpType
+			if opType == "" {
+				opType = src.DefaultOpType
+			}
+			if opType != OpTypeHardlink {
+				continue
+			}
+			ok = ok && isSameFilesystem(d.Dir, src.Dir)
+		}
+		v.addErrWithIndexIf(!ok, ErrKeyDstDirPath, i, ErrDstDirPathHardlinkCrossDevice)
+		allOk = allOk && ok
+	}
+	return allOk
+}
+
+func (v *configValidator) areDstDirsRenameTemplatesAllValid() bool {
+	allOk := true
+	dirs := v.config.Dst.Dirs
+	for i, d := range dirs {
+		_, err := parseTemplate(d.Rename)
+		ok := err == nil
+		v.addErrWithIndexIf(!ok, ErrKeyDstDirRename, i, ErrDstDirRenameNotValid)
+		allOk = allOk && ok
+	}
+	return allOk
+}
+
+func (v *configValidator) areDstDirsSubpathTemplatesAllValid() bool {
+	allOk := true
+	dirs := v.config.Dst.Dirs
+	for i, d := range dirs {
+		ok := isValidSubpathTemplate(d.Subpath)
+		v.addErrWithIndexIf(!ok, ErrKeyDstDirSubpath, i, ErrDstDirSubpathNotValid)
+		allOk = allOk && ok
+	}
+	return allOk
+}
+
 func (v *configValidator) isOpsNumWorkersAtLeastOne() bool {
 	ok := v.config.Ops.NumWorkers >= 1
 	v.addErrIf(!ok, ErrKeyOpsNumWorkers, ErrOpsNumWorkersNotAtLeastOne)
@@ -304,6 +528,13 @@ func (v *configValidator) isOpsMaxTriesLessThanOneMillion() bool {
 	return ok
 }
 
+func (v *configValidator) isOpsCollisionPolicyValid() bool {
+	policy := v.config.Ops.CollisionPolicy
+	ok := policy == "" || policy.IsValid()
+	v.addErrIf(!ok, ErrKeyOpsCollisionPolicy, ErrOpsCollisionPolicyNotValid)
+	return ok
+}
+
 func (v *configValidator) addErrWithIndexIf(add bool, keyFmt string, index int, val string) {
 	key := fmt.Sprintf(keyFmt, index)
 	v.addErrIf(add, key, val)
@@ -329,17 +560,25 @@ func isDir(path string) bool {
 	return err == nil
 }
 
-func isNotEmptyDir(path string, includeSubdirs bool) bool {
-	fis, err := fs.ReadDir(path, &fs.ReadDirOptions{
-		IncludeSubdirs: includeSubdirs,
-		MaxFiles:       1,
-	})
+// isNotEmptyDir returns true if the directory at the given path
+// contains at least one file selected by the given filters, if any.
+func isNotEmptyDir(path string, includeSubdirs bool, filters *SrcFilters) bool {
+	fis, err := readSrcDir(path, includeSubdirs, filters, 1)
 	if err != nil {
 		return false
 	}
 	return len(fis) >= 1
 }
 
+func areValidPatterns(patterns []string) bool {
+	for _, p := range patterns {
+		if _, err := filepath.Match(p, ""); err != nil || p == "" {
+			return false
+		}
+	}
+	return true
+}
+
 func areNotSameDir(path1, path2 string) bool {
 	isSame, err := fs.SameDir(path1, path2)
 	if err != nil {
@@ -348,6 +587,34 @@ func areNotSameDir(path1, path2 string) bool {
 	return !isSame
 }
 
+// isValidSubpathTemplate returns true if the given template can be parsed
+// and its literal text does not lead outside the destination directory.
+func isValidSubpathTemplate(tmpl string) bool {
+	parts, err := parseTemplate(tmpl)
+	if err != nil {
+		return false
+	}
+	if len(parts) > 0 && parts[0].token == nil && filepath.IsAbs(filepath.FromSlash(parts[0].literal)) {
+		return false
+	}
+	for _, p := range parts {
+		for _, elem := range strings.Split(filepath.ToSlash(p.literal), "/") {
+			if elem == ".." {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func isSameFilesystem(path1, path2 string) bool {
+	same, err := sameFilesystem(path1, path2)
+	if err != nil {
+		return false
+	}
+	return same
+}
+
 func isNotChildDirOf(childPath, parentPath string) bool {
 	isChild, err := fs.SubdirOf(childPath, parentPath)
 	if err != nil {
@@ -67,6 +67,16 @@ func TestDefaultConfigValidator_Validate(t *testing.T) {
 	assert.Nil(err)
 	defer os.RemoveAll(dir3)
 
+	// Extra source dir
+	dir4, err := ioutil.TempDir("", "dir4")
+	assert.Nil(err)
+	dir4File1, err := ioutil.TempFile(dir4, "dir4File1")
+	assert.Nil(err)
+	dir4File1.Close()
+	dir4Subdir, err := ioutil.TempDir(dir4, "dir4Subdir")
+	assert.Nil(err)
+	defer os.RemoveAll(dir4)
+
 	tests := []struct {
 		name    string
 		config  *Config
@@ -104,97 +114,727 @@ func TestDefaultConfigValidator_Validate(t *testing.T) {
 				Dst: &ConfigDst{},
 				Ops: &ConfigOps{},
 			},
-			true,
+			true,
+		},
+		{
+			"no config src dir",
+			&Config{
+				Name: "foo",
+				Src:  &ConfigSrc{},
+				Dst:  &ConfigDst{},
+				Ops:  &ConfigOps{},
+			},
+			true,
+		},
+		{
+			"invalid config src dir (1)",
+			&Config{
+				Name: "foo",
+				Src: &ConfigSrc{
+					Dir: "invalid",
+				},
+				Dst: &ConfigDst{},
+				Ops: &ConfigOps{},
+			},
+			true,
+		},
+		{
+			"invalid config src dir (2)",
+			&Config{
+				Name: "foo",
+				Src: &ConfigSrc{
+					Dir: dir1File1.Name(),
+				},
+				Dst: &ConfigDst{},
+				Ops: &ConfigOps{},
+			},
+			true,
+		},
+		{
+			"invalid config src dir (3)",
+			&Config{
+				Name: "foo",
+				Src: &ConfigSrc{
+					Dir: dir2,
+				},
+				Dst: &ConfigDst{},
+				Ops: &ConfigOps{},
+			},
+			true,
+		},
+		{
+			"invalid config src default op type",
+			&Config{
+				Name: "foo",
+				Src: &ConfigSrc{
+					Dir:           dir1,
+					DefaultOpType: "invalid",
+				},
+				Dst: &ConfigDst{},
+				Ops: &ConfigOps{},
+			},
+			true,
+		},
+		{
+			"nil config dst dirs",
+			&Config{
+				Name: "foo",
+				Src: &ConfigSrc{
+					Dir:           dir1,
+					DefaultOpType: OpTypeCopy,
+				},
+				Dst: &ConfigDst{},
+				Ops: &ConfigOps{},
+			},
+			true,
+		},
+		{
+			"empty config dst dirs",
+			&Config{
+				Name: "foo",
+				Src: &ConfigSrc{
+					Dir:           dir1,
+					DefaultOpType: OpTypeCopy,
+				},
+				Dst: &ConfigDst{
+					Dirs: []*DstDir{},
+				},
+				Ops: &ConfigOps{},
+			},
+			true,
+		},
+		{
+			"empty config dst dirs hotkey",
+			&Config{
+				Name: "foo",
+				Src: &ConfigSrc{
+					Dir:           dir1,
+					DefaultOpType: OpTypeCopy,
+				},
+				Dst: &ConfigDst{
+					Dirs: []*DstDir{
+						{Hotkey: "", Dir: ""},
+					},
+				},
+				Ops: &ConfigOps{},
+			},
+			true,
+		},
+		{
+			"long config dst dirs hotkey",
+			&Config{
+				Name: "foo",
+				Src: &ConfigSrc{
+					Dir:           dir1,
+					DefaultOpType: OpTypeCopy,
+				},
+				Dst: &ConfigDst{
+					Dirs: []*DstDir{
+						{Hotkey: "tooLongHotkey", Dir: ""},
+					},
+				},
+				Ops: &ConfigOps{},
+			},
+			true,
+		},
+		{
+			"empty config dst dirs path",
+			&Config{
+				Name: "foo",
+				Src: &ConfigSrc{
+					Dir:           dir1,
+					DefaultOpType: OpTypeCopy,
+				},
+				Dst: &ConfigDst{
+					Dirs: []*DstDir{
+						{Hotkey: "a", Dir: ""},
+					},
+				},
+				Ops: &ConfigOps{},
+			},
+			true,
+		},
+		{
+			"invalid config dst dirs path (1)",
+			&Config{
+				Name: "foo",
+				Src: &ConfigSrc{
+					Dir:           dir1,
+					DefaultOpType: OpTypeCopy,
+				},
+				Dst: &ConfigDst{
+					Dirs: []*DstDir{
+						{Hotkey: "a", Dir: "invalid"},
+					},
+				},
+				Ops: &ConfigOps{},
+			},
+			true,
+		},
+		{
+			"invalid config dst dirs path (2)",
+			&Config{
+				Name: "foo",
+				Src: &ConfigSrc{
+					Dir:           dir1,
+					DefaultOpType: OpTypeCopy,
+				},
+				Dst: &ConfigDst{
+					Dirs: []*DstDir{
+						{Hotkey: "a", Dir: dir1File1.Name()},
+					},
+				},
+				Ops: &ConfigOps{},
+			},
+			true,
+		},
+		{
+			"invalid config dst dirs path (3)",
+			&Config{
+				Name: "foo",
+				Src: &ConfigSrc{
+					Dir:           dir1,
+					DefaultOpType: OpTypeCopy,
+				},
+				Dst: &ConfigDst{
+					Dirs: []*DstDir{
+						{Hotkey: "a", Dir: dir1},
+					},
+				},
+				Ops: &ConfigOps{},
+			},
+			true,
+		},
+		{
+			"invalid config dst dirs path (4)",
+			&Config{
+				Name: "foo",
+				Src: &ConfigSrc{
+					Dir:           dir1,
+					DefaultOpType: OpTypeCopy,
+				},
+				Dst: &ConfigDst{
+					Dirs: []*DstDir{
+						{Hotkey: "a", Dir: dir1Subdir},
+					},
+				},
+				Ops: &ConfigOps{},
+			},
+			true,
+		},
+		{
+			"invalid config ops num workers (1)",
+			&Config{
+				Name: "foo",
+				Src: &ConfigSrc{
+					Dir:           dir1,
+					DefaultOpType: OpTypeCopy,
+				},
+				Dst: &ConfigDst{
+					Dirs: []*DstDir{
+						{Hotkey: "a", Dir: dir2},
+					},
+				},
+				Ops: &ConfigOps{
+					NumWorkers: -1,
+				},
+			},
+			true,
+		},
+		{
+			"invalid config ops num workers (2)",
+			&Config{
+				Name: "foo",
+				Src: &ConfigSrc{
+					Dir:           dir1,
+					DefaultOpType: OpTypeCopy,
+				},
+				Dst: &ConfigDst{
+					Dirs: []*DstDir{
+						{Hotkey: "a", Dir: dir2},
+					},
+				},
+				Ops: &ConfigOps{
+					NumWorkers: 0,
+				},
+			},
+			true,
+		},
+		{
+			"invalid config ops num workers (3)",
+			&Config{
+				Name: "foo",
+				Src: &ConfigSrc{
+					Dir:           dir1,
+					DefaultOpType: OpTypeCopy,
+				},
+				Dst: &ConfigDst{
+					Dirs: []*DstDir{
+						{Hotkey: "a", Dir: dir2},
+					},
+				},
+				Ops: &ConfigOps{
+					NumWorkers: 6,
+				},
+			},
+			true,
+		},
+		{
+			"invalid config ops num workers (4)",
+			&Config{
+				Name: "foo",
+				Src: &ConfigSrc{
+					Dir:           dir1,
+					DefaultOpType: OpTypeCopy,
+				},
+				Dst: &ConfigDst{
+					Dirs: []*DstDir{
+						{Hotkey: "a", Dir: dir2},
+					},
+				},
+				Ops: &ConfigOps{
+					NumWorkers: 100,
+				},
+			},
+			true,
+		},
+		{
+			"invalid config ops max tries (1)",
+			&Config{
+				Name: "foo",
+				Src: &ConfigSrc{
+					Dir:           dir1,
+					DefaultOpType: OpTypeCopy,
+				},
+				Dst: &ConfigDst{
+					Dirs: []*DstDir{
+						{Hotkey: "a", Dir: dir2},
+					},
+				},
+				Ops: &ConfigOps{
+					NumWorkers: 1,
+					MaxTries:   -1,
+				},
+			},
+			true,
+		},
+		{
+			"invalid config ops max tries (2)",
+			&Config{
+				Name: "foo",
+				Src: &ConfigSrc{
+					Dir:           dir1,
+					DefaultOpType: OpTypeCopy,
+				},
+				Dst: &ConfigDst{
+					Dirs: []*DstDir{
+						{Hotkey: "a", Dir: dir2},
+					},
+				},
+				Ops: &ConfigOps{
+					NumWorkers: 1,
+					MaxTries:   0,
+				},
+			},
+			true,
+		},
+		{
+			"invalid config ops max tries (3)",
+			&Config{
+				Name: "foo",
+				Src: &ConfigSrc{
+					Dir:           dir1,
+					DefaultOpType: OpTypeCopy,
+				},
+				Dst: &ConfigDst{
+					Dirs: []*DstDir{
+						{Hotkey: "a", Dir: dir2},
+					},
+				},
+				Ops: &ConfigOps{
+					NumWorkers: 1,
+					MaxTries:   1000001,
+				},
+			},
+			true,
+		},
+		{
+			"invalid config ops max tries (4)",
+			&Config{
+				Name: "foo",
+				Src: &ConfigSrc{
+					Dir:           dir1,
+					DefaultOpType: OpTypeCopy,
+				},
+				Dst: &ConfigDst{
+					Dirs: []*DstDir{
+						{Hotkey: "a", Dir: dir2},
+					},
+				},
+				Ops: &ConfigOps{
+					NumWorkers: 1,
+					MaxTries:   1000000000,
+				},
+			},
+			true,
+		},
+		{
+			"invalid config dst dir op type",
+			&Config{
+				Name: "foo",
+				Src: &ConfigSrc{
+					Dir:           dir1,
+					DefaultOpType: OpTypeCopy,
+				},
+				Dst: &ConfigDst{
+					Dirs: []*DstDir{
+						{Hotkey: "a", Dir: dir2, OpType: "invalid"},
+					},
+				},
+				Ops: &ConfigOps{
+					NumWorkers: 1,
+					MaxTries:   1,
+				},
+			},
+			true,
+		},
+		{
+			"valid config with dst dir op type",
+			&Config{
+				Name: "foo",
+				Src: &ConfigSrc{
+					Dir:           dir1,
+					DefaultOpType: OpTypeCopy,
+				},
+				Dst: &ConfigDst{
+					Dirs: []*DstDir{
+						{Hotkey: "a", Dir: dir2},
+						{Hotkey: "b", Dir: dir3, OpType: OpTypeMove},
+					},
+				},
+				Ops: &ConfigOps{
+					NumWorkers: 1,
+					MaxTries:   1,
+				},
+			},
+			false,
+		},
+		{
+			"valid config (1)",
+			&Config{
+				Name: "foo",
+				Src: &ConfigSrc{
+					Dir:           dir1,
+					DefaultOpType: OpTypeCopy,
+				},
+				Dst: &ConfigDst{
+					Dirs: []*DstDir{
+						{Hotkey: "a", Dir: dir2},
+					},
+				},
+				Ops: &ConfigOps{
+					NumWorkers: 1,
+					MaxTries:   1,
+				},
+			},
+			false,
+		},
+		{
+			"valid config (2)",
+			&Config{
+				Name: "foo",
+				Src: &ConfigSrc{
+					Dir:           dir1,
+					DefaultOpType: OpTypeMove,
+				},
+				Dst: &ConfigDst{
+					Dirs: []*DstDir{
+						{Hotkey: "a", Dir: dir2},
+					},
+				},
+				Ops: &ConfigOps{
+					NumWorkers: 1,
+					MaxTries:   1,
+				},
+			},
+			false,
+		},
+		{
+			"valid config (3)",
+			&Config{
+				Name: "foo",
+				Src: &ConfigSrc{
+					Dir:           dir1,
+					DefaultOpType: OpTypeMove,
+				},
+				Dst: &ConfigDst{
+					Dirs: []*DstDir{
+						{Hotkey: "a", Dir: dir2},
+						{Hotkey: "b", Dir: dir2},
+					},
+				},
+				Ops: &ConfigOps{
+					NumWorkers: 1,
+					MaxTries:   1,
+				},
+			},
+			false,
+		},
+		{
+			"valid config (4)",
+			&Config{
+				Name: "foo",
+				Src: &ConfigSrc{
+					Dir:            dir1,
+					DefaultOpType:  OpTypeMove,
+					IncludeSubdirs: true,
+				},
+				Dst: &ConfigDst{
+					Dirs: []*DstDir{
+						{Hotkey: "a", Dir: dir2},
+						{Hotkey: "b", Dir: dir2},
+					},
+				},
+				Ops: &ConfigOps{
+					NumWorkers: 1,
+					MaxTries:   1,
+				},
+			},
+			false,
+		},
+		{
+			"valid config (5)",
+			&Config{
+				Name: "foo",
+				Src: &ConfigSrc{
+					Dir:            dir1,
+					DefaultOpType:  OpTypeCopy,
+					IncludeSubdirs: true,
+				},
+				Dst: &ConfigDst{
+					Dirs: []*DstDir{
+						{Hotkey: "0", Dir: dir2},
+						{Hotkey: "1", Dir: dir2},
+						{Hotkey: "a", Dir: dir2},
+						{Hotkey: "b", Dir: dir2},
+						{Hotkey: "C", Dir: dir2},
+						{Hotkey: "Д", Dir: dir3},
+						{Hotkey: "è", Dir: dir3},
+						{Hotkey: "β", Dir: dir3},
+						{Hotkey: "あ", Dir: dir3},
+						{Hotkey: "𛀀", Dir: dir3},
+						{Hotkey: "'", Dir: dir3},
+						{Hotkey: `\`, Dir: dir3},
+					},
+				},
+				Ops: &ConfigOps{
+					NumWorkers: 1,
+					MaxTries:   1,
+				},
+			},
+			false,
+		},
+		{
+			"valid config (6)",
+			&Config{
+				Name: "foo",
+				Src: &ConfigSrc{
+					Dir:            dir1,
+					DefaultOpType:  OpTypeCopy,
+					IncludeSubdirs: true,
+				},
+				Dst: &ConfigDst{
+					Dirs: []*DstDir{
+						{Hotkey: "0", Dir: dir2},
+						{Hotkey: "1", Dir: dir2},
+						{Hotkey: "a", Dir: dir2},
+						{Hotkey: "b", Dir: dir2},
+						{Hotkey: "C", Dir: dir2},
+						{Hotkey: "Д", Dir: dir3},
+						{Hotkey: "è", Dir: dir3},
+						{Hotkey: "β", Dir: dir3},
+						{Hotkey: "あ", Dir: dir3},
+						{Hotkey: "𛀀", Dir: dir3},
+						{Hotkey: "'", Dir: dir3},
+						{Hotkey: `\`, Dir: dir3},
+					},
+				},
+				Ops: &ConfigOps{
+					NumWorkers: 5,
+					MaxTries:   1000000,
+				},
+			},
+			false,
+		},
+		{
+			"valid config with shift hotkeys",
+			&Config{
+				Name: "foo",
+				Src: &ConfigSrc{
+					Dir:           dir1,
+					DefaultOpType: OpTypeCopy,
+				},
+				Dst: &ConfigDst{
+					Dirs: []*DstDir{
+						{Hotkey: "a", Dir: dir2},
+						{Hotkey: "Shift+a", Dir: dir3},
+						{Hotkey: "Shift+β", Dir: dir3},
+					},
+				},
+				Ops: &ConfigOps{
+					NumWorkers: 1,
+					MaxTries:   1,
+				},
+			},
+			false,
 		},
 		{
-			"no config src dir",
+			"invalid config shift hotkey not one rune",
 			&Config{
 				Name: "foo",
-				Src:  &ConfigSrc{},
-				Dst:  &ConfigDst{},
-				Ops:  &ConfigOps{},
+				Src: &ConfigSrc{
+					Dir:           dir1,
+					DefaultOpType: OpTypeCopy,
+				},
+				Dst: &ConfigDst{
+					Dirs: []*DstDir{
+						{Hotkey: "Shift+ab", Dir: dir2},
+					},
+				},
+				Ops: &ConfigOps{
+					NumWorkers: 1,
+					MaxTries:   1,
+				},
 			},
 			true,
 		},
 		{
-			"invalid config src dir (1)",
+			"invalid config shift hotkeys not distinct",
 			&Config{
 				Name: "foo",
 				Src: &ConfigSrc{
-					Dir: "invalid",
+					Dir:           dir1,
+					DefaultOpType: OpTypeCopy,
+				},
+				Dst: &ConfigDst{
+					Dirs: []*DstDir{
+						{Hotkey: "Shift+a", Dir: dir2},
+						{Hotkey: "Shift+a", Dir: dir3},
+					},
+				},
+				Ops: &ConfigOps{
+					NumWorkers: 1,
+					MaxTries:   1,
 				},
-				Dst: &ConfigDst{},
-				Ops: &ConfigOps{},
 			},
 			true,
 		},
 		{
-			"invalid config src dir (2)",
+			"valid config with collision policy",
 			&Config{
 				Name: "foo",
 				Src: &ConfigSrc{
-					Dir: dir1File1.Name(),
+					Dir:           dir1,
+					DefaultOpType: OpTypeCopy,
+				},
+				Dst: &ConfigDst{
+					Dirs: []*DstDir{
+						{Hotkey: "a", Dir: dir2},
+					},
+				},
+				Ops: &ConfigOps{
+					NumWorkers:      1,
+					MaxTries:        1,
+					CollisionPolicy: CollisionPolicyIdentical,
 				},
-				Dst: &ConfigDst{},
-				Ops: &ConfigOps{},
 			},
-			true,
+			false,
 		},
 		{
-			"invalid config src dir (3)",
+			"invalid config collision policy",
 			&Config{
 				Name: "foo",
 				Src: &ConfigSrc{
-					Dir: dir2,
+					Dir:           dir1,
+					DefaultOpType: OpTypeCopy,
+				},
+				Dst: &ConfigDst{
+					Dirs: []*DstDir{
+						{Hotkey: "a", Dir: dir2},
+					},
+				},
+				Ops: &ConfigOps{
+					NumWorkers:      1,
+					MaxTries:        1,
+					CollisionPolicy: "merge",
 				},
-				Dst: &ConfigDst{},
-				Ops: &ConfigOps{},
 			},
 			true,
 		},
 		{
-			"invalid config src default op type",
+			"valid config with trash dst dir",
 			&Config{
 				Name: "foo",
 				Src: &ConfigSrc{
 					Dir:           dir1,
-					DefaultOpType: "invalid",
+					DefaultOpType: OpTypeCopy,
+				},
+				Dst: &ConfigDst{
+					Dirs: []*DstDir{
+						{Hotkey: "a", Dir: dir2},
+						{Hotkey: "d", OpType: OpTypeTrash},
+					},
+				},
+				Ops: &ConfigOps{
+					NumWorkers: 1,
+					MaxTries:   1,
 				},
-				Dst: &ConfigDst{},
-				Ops: &ConfigOps{},
 			},
-			true,
+			false,
 		},
 		{
-			"nil config dst dirs",
+			"invalid config default op type trash",
 			&Config{
 				Name: "foo",
 				Src: &ConfigSrc{
 					Dir:           dir1,
-					DefaultOpType: OpTypeCopy,
+					DefaultOpType: OpTypeTrash,
+				},
+				Dst: &ConfigDst{
+					Dirs: []*DstDir{
+						{Hotkey: "a", Dir: dir2},
+					},
+				},
+				Ops: &ConfigOps{
+					NumWorkers: 1,
+					MaxTries:   1,
 				},
-				Dst: &ConfigDst{},
-				Ops: &ConfigOps{},
 			},
 			true,
 		},
 		{
-			"empty config dst dirs",
+			"valid config with hardlink dst dir",
 			&Config{
 				Name: "foo",
 				Src: &ConfigSrc{
 					Dir:           dir1,
-					DefaultOpType: OpTypeCopy,
+					DefaultOpType: OpTypeHardlink,
 				},
 				Dst: &ConfigDst{
-					Dirs: []*DstDir{},
+					Dirs: []*DstDir{
+						{Hotkey: "a", Dir: dir2},
+						{Hotkey: "b", Dir: dir3, OpType: OpTypeSymlink},
+					},
+				},
+				Ops: &ConfigOps{
+					NumWorkers: 1,
+					MaxTries:   1,
 				},
-				Ops: &ConfigOps{},
 			},
-			true,
+			false,
 		},
 		{
-			"empty config dst dirs hotkey",
+			"valid config with rename template",
 			&Config{
 				Name: "foo",
 				Src: &ConfigSrc{
@@ -203,15 +843,18 @@ func TestDefaultConfigValidator_Validate(t *testing.T) {
 				},
 				Dst: &ConfigDst{
 					Dirs: []*DstDir{
-						{"", ""},
+						{Hotkey: "a", Dir: dir2, Rename: "{mtime:2006-01-02}_{name}_{counter:3}.{ext}"},
 					},
 				},
-				Ops: &ConfigOps{},
+				Ops: &ConfigOps{
+					NumWorkers: 1,
+					MaxTries:   1,
+				},
 			},
-			true,
+			false,
 		},
 		{
-			"long config dst dirs hotkey",
+			"invalid config rename template",
 			&Config{
 				Name: "foo",
 				Src: &ConfigSrc{
@@ -220,15 +863,18 @@ func TestDefaultConfigValidator_Validate(t *testing.T) {
 				},
 				Dst: &ConfigDst{
 					Dirs: []*DstDir{
-						{"tooLongHotkey", ""},
+						{Hotkey: "a", Dir: dir2, Rename: "{date}"},
 					},
 				},
-				Ops: &ConfigOps{},
+				Ops: &ConfigOps{
+					NumWorkers: 1,
+					MaxTries:   1,
+				},
 			},
 			true,
 		},
 		{
-			"empty config dst dirs path",
+			"valid config with subpath template",
 			&Config{
 				Name: "foo",
 				Src: &ConfigSrc{
@@ -237,15 +883,18 @@ func TestDefaultConfigValidator_Validate(t *testing.T) {
 				},
 				Dst: &ConfigDst{
 					Dirs: []*DstDir{
-						{"a", ""},
+						{Hotkey: "a", Dir: dir2, Subpath: "{mtime:2006}/{mtime:01}/{ext}"},
 					},
 				},
-				Ops: &ConfigOps{},
+				Ops: &ConfigOps{
+					NumWorkers: 1,
+					MaxTries:   1,
+				},
 			},
-			true,
+			false,
 		},
 		{
-			"invalid config dst dirs path (1)",
+			"invalid config subpath template",
 			&Config{
 				Name: "foo",
 				Src: &ConfigSrc{
@@ -254,15 +903,18 @@ func TestDefaultConfigValidator_Validate(t *testing.T) {
 				},
 				Dst: &ConfigDst{
 					Dirs: []*DstDir{
-						{"a", "invalid"},
+						{Hotkey: "a", Dir: dir2, Subpath: "{year}"},
 					},
 				},
-				Ops: &ConfigOps{},
+				Ops: &ConfigOps{
+					NumWorkers: 1,
+					MaxTries:   1,
+				},
 			},
 			true,
 		},
 		{
-			"invalid config dst dirs path (2)",
+			"invalid config subpath template outside destination",
 			&Config{
 				Name: "foo",
 				Src: &ConfigSrc{
@@ -271,214 +923,248 @@ func TestDefaultConfigValidator_Validate(t *testing.T) {
 				},
 				Dst: &ConfigDst{
 					Dirs: []*DstDir{
-						{"a", dir1File1.Name()},
+						{Hotkey: "a", Dir: dir2, Subpath: "../{ext}"},
 					},
 				},
-				Ops: &ConfigOps{},
+				Ops: &ConfigOps{
+					NumWorkers: 1,
+					MaxTries:   1,
+				},
 			},
 			true,
 		},
 		{
-			"invalid config dst dirs path (3)",
+			"valid config with filters",
 			&Config{
 				Name: "foo",
 				Src: &ConfigSrc{
 					Dir:           dir1,
 					DefaultOpType: OpTypeCopy,
+					Filters:       &SrcFilters{Exclude: []string{"*.txt"}, SkipHidden: true, MtimeFrom: "2000-01-01"},
 				},
 				Dst: &ConfigDst{
 					Dirs: []*DstDir{
-						{"a", dir1},
+						{Hotkey: "a", Dir: dir2},
 					},
 				},
-				Ops: &ConfigOps{},
+				Ops: &ConfigOps{
+					NumWorkers: 1,
+					MaxTries:   1,
+				},
 			},
-			true,
+			false,
 		},
 		{
-			"invalid config dst dirs path (4)",
+			"invalid config filters pattern",
 			&Config{
 				Name: "foo",
 				Src: &ConfigSrc{
 					Dir:           dir1,
 					DefaultOpType: OpTypeCopy,
+					Filters:       &SrcFilters{Include: []string{"[a-"}},
 				},
 				Dst: &ConfigDst{
 					Dirs: []*DstDir{
-						{"a", dir1Subdir},
+						{Hotkey: "a", Dir: dir2},
 					},
 				},
-				Ops: &ConfigOps{},
+				Ops: &ConfigOps{
+					NumWorkers: 1,
+					MaxTries:   1,
+				},
 			},
 			true,
 		},
 		{
-			"invalid config ops num workers (1)",
+			"invalid config filters size range",
 			&Config{
 				Name: "foo",
 				Src: &ConfigSrc{
 					Dir:           dir1,
 					DefaultOpType: OpTypeCopy,
+					Filters:       &SrcFilters{MinSize: 10, MaxSize: 5},
 				},
 				Dst: &ConfigDst{
 					Dirs: []*DstDir{
-						{"a", dir2},
+						{Hotkey: "a", Dir: dir2},
 					},
 				},
 				Ops: &ConfigOps{
-					NumWorkers: -1,
+					NumWorkers: 1,
+					MaxTries:   1,
 				},
 			},
 			true,
 		},
 		{
-			"invalid config ops num workers (2)",
+			"invalid config filters date range",
 			&Config{
 				Name: "foo",
 				Src: &ConfigSrc{
 					Dir:           dir1,
 					DefaultOpType: OpTypeCopy,
+					Filters:       &SrcFilters{MtimeFrom: "2024-03-15", MtimeTo: "2024-03-01"},
 				},
 				Dst: &ConfigDst{
 					Dirs: []*DstDir{
-						{"a", dir2},
+						{Hotkey: "a", Dir: dir2},
 					},
 				},
 				Ops: &ConfigOps{
-					NumWorkers: 0,
+					NumWorkers: 1,
+					MaxTries:   1,
 				},
 			},
 			true,
 		},
 		{
-			"invalid config ops num workers (3)",
+			"invalid config filters date",
 			&Config{
 				Name: "foo",
 				Src: &ConfigSrc{
 					Dir:           dir1,
 					DefaultOpType: OpTypeCopy,
+					Filters:       &SrcFilters{MtimeFrom: "15/03/2024"},
 				},
 				Dst: &ConfigDst{
 					Dirs: []*DstDir{
-						{"a", dir2},
+						{Hotkey: "a", Dir: dir2},
 					},
 				},
 				Ops: &ConfigOps{
-					NumWorkers: 6,
+					NumWorkers: 1,
+					MaxTries:   1,
 				},
 			},
 			true,
 		},
 		{
-			"invalid config ops num workers (4)",
+			"invalid config filters leaving no files",
 			&Config{
 				Name: "foo",
 				Src: &ConfigSrc{
 					Dir:           dir1,
 					DefaultOpType: OpTypeCopy,
+					Filters:       &SrcFilters{Extensions: []string{"none"}},
 				},
 				Dst: &ConfigDst{
 					Dirs: []*DstDir{
-						{"a", dir2},
+						{Hotkey: "a", Dir: dir2},
 					},
 				},
 				Ops: &ConfigOps{
-					NumWorkers: 100,
+					NumWorkers: 1,
+					MaxTries:   1,
 				},
 			},
 			true,
 		},
 		{
-			"invalid config ops max tries (1)",
+			"valid config with extra source directory",
 			&Config{
 				Name: "foo",
 				Src: &ConfigSrc{
 					Dir:           dir1,
 					DefaultOpType: OpTypeCopy,
+					ExtraDirs: []*SrcDir{
+						{Dir: dir4, DefaultOpType: OpTypeMove},
+					},
 				},
 				Dst: &ConfigDst{
 					Dirs: []*DstDir{
-						{"a", dir2},
+						{Hotkey: "a", Dir: dir2},
 					},
 				},
 				Ops: &ConfigOps{
 					NumWorkers: 1,
-					MaxTries:   -1,
+					MaxTries:   1,
 				},
 			},
-			true,
+			false,
 		},
 		{
-			"invalid config ops max tries (2)",
+			"invalid config extra source directory nil",
 			&Config{
 				Name: "foo",
 				Src: &ConfigSrc{
 					Dir:           dir1,
 					DefaultOpType: OpTypeCopy,
+					ExtraDirs: []*SrcDir{
+						nil,
+					},
 				},
 				Dst: &ConfigDst{
 					Dirs: []*DstDir{
-						{"a", dir2},
+						{Hotkey: "a", Dir: dir2},
 					},
 				},
 				Ops: &ConfigOps{
 					NumWorkers: 1,
-					MaxTries:   0,
+					MaxTries:   1,
 				},
 			},
 			true,
 		},
 		{
-			"invalid config ops max tries (3)",
+			"invalid config extra source directory path empty",
 			&Config{
 				Name: "foo",
 				Src: &ConfigSrc{
 					Dir:           dir1,
 					DefaultOpType: OpTypeCopy,
+					ExtraDirs: []*SrcDir{
+						{Dir: "", DefaultOpType: OpTypeMove},
+					},
 				},
 				Dst: &ConfigDst{
 					Dirs: []*DstDir{
-						{"a", dir2},
+						{Hotkey: "a", Dir: dir2},
 					},
 				},
 				Ops: &ConfigOps{
 					NumWorkers: 1,
-					MaxTries:   1000001,
+					MaxTries:   1,
 				},
 			},
 			true,
 		},
 		{
-			"invalid config ops max tries (4)",
+			"invalid config extra source directory path not valid",
 			&Config{
 				Name: "foo",
 				Src: &ConfigSrc{
 					Dir:           dir1,
 					DefaultOpType: OpTypeCopy,
+					ExtraDirs: []*SrcDir{
+						{Dir: dir1File1.Name(), DefaultOpType: OpTypeMove},
+					},
 				},
 				Dst: &ConfigDst{
 					Dirs: []*DstDir{
-						{"a", dir2},
+						{Hotkey: "a", Dir: dir2},
 					},
 				},
 				Ops: &ConfigOps{
 					NumWorkers: 1,
-					MaxTries:   1000000000,
+					MaxTries:   1,
 				},
 			},
 			true,
 		},
 		{
-			"valid config (1)",
+			"invalid config extra source directory same as main one",
 			&Config{
 				Name: "foo",
 				Src: &ConfigSrc{
 					Dir:           dir1,
 					DefaultOpType: OpTypeCopy,
+					ExtraDirs: []*SrcDir{
+						{Dir: dir1, DefaultOpType: OpTypeMove},
+					},
 				},
 				Dst: &ConfigDst{
 					Dirs: []*DstDir{
-						{"a", dir2},
+						{Hotkey: "a", Dir: dir2},
 					},
 				},
 				Ops: &ConfigOps{
@@ -486,19 +1172,22 @@ func TestDefaultConfigValidator_Validate(t *testing.T) {
 					MaxTries:   1,
 				},
 			},
-			false,
+			true,
 		},
 		{
-			"valid config (2)",
+			"invalid config extra source directory containing main one",
 			&Config{
 				Name: "foo",
 				Src: &ConfigSrc{
 					Dir:           dir1,
-					DefaultOpType: OpTypeMove,
+					DefaultOpType: OpTypeCopy,
+					ExtraDirs: []*SrcDir{
+						{Dir: filepath.Dir(dir1), IncludeSubdirs: true, DefaultOpType: OpTypeMove},
+					},
 				},
 				Dst: &ConfigDst{
 					Dirs: []*DstDir{
-						{"a", dir2},
+						{Hotkey: "a", Dir: dir2},
 					},
 				},
 				Ops: &ConfigOps{
@@ -506,20 +1195,22 @@ func TestDefaultConfigValidator_Validate(t *testing.T) {
 					MaxTries:   1,
 				},
 			},
-			false,
+			true,
 		},
 		{
-			"valid config (3)",
+			"invalid config extra source directory empty",
 			&Config{
 				Name: "foo",
 				Src: &ConfigSrc{
 					Dir:           dir1,
-					DefaultOpType: OpTypeMove,
+					DefaultOpType: OpTypeCopy,
+					ExtraDirs: []*SrcDir{
+						{Dir: dir3, DefaultOpType: OpTypeMove},
+					},
 				},
 				Dst: &ConfigDst{
 					Dirs: []*DstDir{
-						{"a", dir2},
-						{"b", dir2},
+						{Hotkey: "a", Dir: dir2},
 					},
 				},
 				Ops: &ConfigOps{
@@ -527,21 +1218,22 @@ func TestDefaultConfigValidator_Validate(t *testing.T) {
 					MaxTries:   1,
 				},
 			},
-			false,
+			true,
 		},
 		{
-			"valid config (4)",
+			"invalid config extra source directory default operation trash",
 			&Config{
 				Name: "foo",
 				Src: &ConfigSrc{
-					Dir:            dir1,
-					DefaultOpType:  OpTypeMove,
-					IncludeSubdirs: true,
+					Dir:           dir1,
+					DefaultOpType: OpTypeCopy,
+					ExtraDirs: []*SrcDir{
+						{Dir: dir4, DefaultOpType: OpTypeTrash},
+					},
 				},
 				Dst: &ConfigDst{
 					Dirs: []*DstDir{
-						{"a", dir2},
-						{"b", dir2},
+						{Hotkey: "a", Dir: dir2},
 					},
 				},
 				Ops: &ConfigOps{
@@ -549,31 +1241,22 @@ func TestDefaultConfigValidator_Validate(t *testing.T) {
 					MaxTries:   1,
 				},
 			},
-			false,
+			true,
 		},
 		{
-			"valid config (5)",
+			"invalid config destination directory same as extra source directory",
 			&Config{
 				Name: "foo",
 				Src: &ConfigSrc{
-					Dir:            dir1,
-					DefaultOpType:  OpTypeCopy,
-					IncludeSubdirs: true,
+					Dir:           dir1,
+					DefaultOpType: OpTypeCopy,
+					ExtraDirs: []*SrcDir{
+						{Dir: dir4, DefaultOpType: OpTypeMove},
+					},
 				},
 				Dst: &ConfigDst{
 					Dirs: []*DstDir{
-						{"0", dir2},
-						{"1", dir2},
-						{"a", dir2},
-						{"b", dir2},
-						{"C", dir2},
-						{"Д", dir3},
-						{"è", dir3},
-						{"β", dir3},
-						{"あ", dir3},
-						{"𛀀", dir3},
-						{"'", dir3},
-						{`\`, dir3},
+						{Hotkey: "a", Dir: dir4},
 					},
 				},
 				Ops: &ConfigOps{
@@ -581,39 +1264,30 @@ func TestDefaultConfigValidator_Validate(t *testing.T) {
 					MaxTries:   1,
 				},
 			},
-			false,
+			true,
 		},
 		{
-			"valid config (6)",
+			"invalid config destination directory inside extra source directory",
 			&Config{
 				Name: "foo",
 				Src: &ConfigSrc{
-					Dir:            dir1,
-					DefaultOpType:  OpTypeCopy,
-					IncludeSubdirs: true,
+					Dir:           dir1,
+					DefaultOpType: OpTypeCopy,
+					ExtraDirs: []*SrcDir{
+						{Dir: dir4, DefaultOpType: OpTypeMove},
+					},
 				},
 				Dst: &ConfigDst{
 					Dirs: []*DstDir{
-						{"0", dir2},
-						{"1", dir2},
-						{"a", dir2},
-						{"b", dir2},
-						{"C", dir2},
-						{"Д", dir3},
-						{"è", dir3},
-						{"β", dir3},
-						{"あ", dir3},
-						{"𛀀", dir3},
-						{"'", dir3},
-						{`\`, dir3},
+						{Hotkey: "a", Dir: dir4Subdir},
 					},
 				},
 				Ops: &ConfigOps{
-					NumWorkers: 5,
-					MaxTries:   1000000,
+					NumWorkers: 1,
+					MaxTries:   1,
 				},
 			},
-			false,
+			true,
 		},
 	}
 	for _, tt := range tests {
@@ -696,6 +1370,30 @@ func Test_areNotSameDir(t *testing.T) {
 	}
 }
 
+func Test_isSameFilesystem(t *testing.T) {
+	assert := assert.New(t)
+
+	dir1, err := ioutil.TempDir("", "dir")
+	assert.Nil(err)
+	defer os.RemoveAll(dir1)
+	dir2, err := ioutil.TempDir("", "dir")
+	assert.Nil(err)
+	defer os.RemoveAll(dir2)
+
+	assert.True(isSameFilesystem(dir1, dir2))
+	assert.False(isSameFilesystem(dir1, filepath.Join(dir2, "nonexistent")))
+
+	// A memory filesystem, if available, is on another device
+	otherDir, err := ioutil.TempDir("/dev/shm", "dir")
+	if err != nil {
+		return
+	}
+	defer os.RemoveAll(otherDir)
+	if same, err := sameFilesystem("/dev/shm", os.TempDir()); err == nil && !same {
+		assert.False(isSameFilesystem(dir1, otherDir))
+	}
+}
+
 func Test_isNotChildDirOf(t *testing.T) {
 	assert := assert.New(t)
 
@@ -797,41 +1495,61 @@ func Test_isNotEmptyDir(t *testing.T) {
 		name           string
 		path           string
 		includeSubdirs bool
+		filters        *SrcFilters
 		want           bool
 	}{
 		{
 			"invalid dir",
 			"",
 			false,
+			nil,
 			false,
 		},
 		{
 			"empty dir (1)",
 			dir1,
 			false,
+			nil,
 			false,
 		},
 		{
 			"empty dir (2)",
 			dir1,
 			true,
+			nil,
 			false,
 		},
 		{
 			"non-empty dir (1)",
 			dir2,
 			false,
+			nil,
 			true,
 		},
 		{
 			"non-empty dir (2)",
 			dir2,
 			true,
+			nil,
+			true,
+		},
+		{
+			"non-empty dir with matching filters",
+			dir2,
+			false,
+			&SrcFilters{Extensions: []string{"txt"}},
 			true,
 		},
+		{
+			"non-empty dir with no matching files",
+			dir2,
+			false,
+			&SrcFilters{Exclude: []string{"*.txt"}},
+			false,
+		},
 	}
 	for _, tt := range tests {
-		got := isNotEmptyDir(tt.path, tt.includeSubdirs)
+		got := isNotEmptyDir(tt.path, tt.includeSubdirs, tt.filters)
 		assert.Equal(tt.want, got, tt.name)
 	}
 }
@@ -1,12 +1,26 @@
 package core
 
+import "time"
+
 // File represents a regular file managed by the organizer.
+// SrcID identifies the source directory containing the file:
+// 0 for the main source directory and i+1 for ConfigSrc.ExtraDirs[i].
+// Gone is true if the file vanished from the source directory
+// without being sent to a destination directory.
 type File struct {
-	ID   int64  `json:"id"`
-	Name string `json:"name"`
-	Dir  string `json:"dir"`
-	Path string `json:"path"`
-	Ext  string `json:"ext"`
-	Size int64  `json:"size"`
-	URL  string `json:"url"`
+	ID    int64  `json:"id"`
+	SrcID int    `json:"srcId"`
+	Name  string `json:"name"`
+	Dir   string `json:"dir"`
+	Path  string `json:"path"`
+	Ext   string `json:"ext"`
+	Size  int64  `json:"size"`
+	URL   string `json:"url"`
+	Gone  bool   `json:"gone"`
+
+	// modTime is the modification time of the file when it was last observed,
+	// with its size, and unchangedSince is when they were first observed
+	// with their current values; see observeFile.
+	modTime        time.Time
+	unchangedSince time.Time
 }
@@ -1,6 +1,7 @@
 package core
 
 import (
+	"fmt"
 	"net/http"
 	"time"
 )
@@ -8,17 +9,23 @@ import (
 const defaultFileServerAddr = "http://localhost:5921"
 const defaultFileServerPort = ":5921"
 
-// FileServer represents an HTTP server that serves a static directory.
+// FileServer represents an HTTP server that serves static directories.
 type FileServer struct {
 	server *http.Server
 }
 
-// NewFileServer creates a new FileServer serving the files present in the given directory.
-func NewFileServer(dir string) *FileServer {
+// NewFileServer creates a new FileServer serving the files present in the given directories.
+// The files of the directory at index i are served under the path /i/.
+func NewFileServer(dirs ...string) *FileServer {
+	mux := http.NewServeMux()
+	for i, dir := range dirs {
+		prefix := fmt.Sprintf("/%d/", i)
+		mux.Handle(prefix, http.StripPrefix(prefix, http.FileServer(http.Dir(dir))))
+	}
 	fs := &FileServer{
 		server: &http.Server{
 			Addr:    defaultFileServerPort,
-			Handler: http.FileServer(http.Dir(dir)),
+			Handler: mux,
 		},
 	}
 	go func() {
@@ -4,6 +4,7 @@ import (
 	"io/ioutil"
 	"net/http"
 	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -25,6 +26,48 @@ func TestNewFileServer(t *testing.T) {
 	resp.Body.Close()
 }
 
+func TestNewFileServerMultipleDirs(t *testing.T) {
+	assert := assert.New(t)
+
+	dir1, err := ioutil.TempDir("", "dir1")
+	assert.Nil(err)
+	defer os.RemoveAll(dir1)
+	dir2, err := ioutil.TempDir("", "dir2")
+	assert.Nil(err)
+	defer os.RemoveAll(dir2)
+
+	err = ioutil.WriteFile(filepath.Join(dir1, "a.txt"), []byte("a"), 0644)
+	assert.Nil(err)
+	err = ioutil.WriteFile(filepath.Join(dir2, "b.txt"), []byte("b"), 0644)
+	assert.Nil(err)
+
+	fs := NewFileServer(dir1, dir2)
+	assert.NotNil(fs)
+	defer fs.Close()
+
+	tests := []struct {
+		path       string
+		statusCode int
+		body       string
+	}{
+		{"/0/a.txt", http.StatusOK, "a"},
+		{"/1/b.txt", http.StatusOK, "b"},
+		{"/0/b.txt", http.StatusNotFound, ""},
+		{"/2/a.txt", http.StatusNotFound, ""},
+	}
+	for _, tt := range tests {
+		resp, err := http.Get(defaultFileServerAddr + tt.path)
+		assert.Nil(err, tt.path)
+		body, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		assert.Nil(err, tt.path)
+		assert.Equal(tt.statusCode, resp.StatusCode, tt.path)
+		if tt.statusCode == http.StatusOK {
+			assert.Equal(tt.body, string(body), tt.path)
+		}
+	}
+}
+
 func TestFileServer_Close(t *testing.T) {
 	assert := assert.New(t)
 
@@ -0,0 +1,34 @@
+//go:build !windows
+// +build !windows
+
+package core
+
+import (
+	"os"
+	"syscall"
+)
+
+// sameFilesystem returns true if the files at the given paths are on the same filesystem.
+func sameFilesystem(path1, path2 string) (bool, error) {
+	dev1, err := device(path1)
+	if err != nil {
+		return false, err
+	}
+	dev2, err := device(path2)
+	if err != nil {
+		return false, err
+	}
+	return dev1 == dev2, nil
+}
+
+func device(path string) (uint64, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0, err
+	}
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, syscall.ENOTSUP
+	}
+	return uint64(stat.Dev), nil
+}
@@ -0,0 +1,22 @@
+//go:build windows
+// +build windows
+
+package core
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// sameFilesystem returns true if the files at the given paths are on the same volume.
+func sameFilesystem(path1, path2 string) (bool, error) {
+	abs1, err := filepath.Abs(path1)
+	if err != nil {
+		return false, err
+	}
+	abs2, err := filepath.Abs(path2)
+	if err != nil {
+		return false, err
+	}
+	return strings.EqualFold(filepath.VolumeName(abs1), filepath.VolumeName(abs2)), nil
+}
@@ -0,0 +1,195 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/velut/fsutils-go/fs"
+)
+
+// srcFiltersDateLayout is the layout of the dates bounding the modification time of files.
+const srcFiltersDateLayout = "2006-01-02"
+
+// SrcFilters contains the filters selecting the files to organize in the source directory.
+// Include and Exclude contain shell patterns, as defined by filepath.Match,
+// matched against the file name or, for patterns containing a slash,
+// against the slash-separated path relative to the source directory.
+// If Include is not empty, files must match at least one of its patterns;
+// files matching any pattern in Exclude are left out.
+// If Extensions is not empty, only files with one of the given extensions,
+// with or without the leading dot, are selected; the comparison ignores case.
+// MinSize and MaxSize bound the file size in bytes; zero means no bound.
+// MtimeFrom and MtimeTo bound, inclusively, the modification date of files,
+// in the local time zone and formatted as "2006-01-02"; empty means no bound.
+// If SkipHidden is true, files whose name or directory, relative to
+// the source directory, starts with a dot are left out.
+type SrcFilters struct {
+	Include    []string `json:"include"`
+	Exclude    []string `json:"exclude"`
+	Extensions []string `json:"extensions"`
+	MinSize    int64    `json:"minSize"`
+	MaxSize    int64    `json:"maxSize"`
+	MtimeFrom  string   `json:"mtimeFrom"`
+	MtimeTo    string   `json:"mtimeTo"`
+	SkipHidden bool     `json:"skipHidden"`
+}
+
+// readSrcDir returns the regular files in the given directory, and its subdirectories
+// if includeSubdirs is true, not ignored by the .teclaignore files
+// and selected by the given filters, if any.
+// The .teclaignore files themselves are left out.
+// If maxFiles is not zero, at most maxFiles files are returned.
+func readSrcDir(dir string, includeSubdirs bool, filters *SrcFilters, maxFiles int) ([]*fs.FileInfo, error) {
+	fileInfos, err := fs.ReadDir(dir, &fs.ReadDirOptions{
+		IncludeSubdirs: includeSubdirs,
+	})
+	if err != nil {
+		return nil, err
+	}
+	ignore, err := readIgnoreRules(dir, fileInfos)
+	if err != nil {
+		return nil, err
+	}
+
+	selected := []*fs.FileInfo{}
+	for _, fi := range fileInfos {
+		if maxFiles > 0 && len(selected) == maxFiles {
+			break
+		}
+		if fi.Name == teclaIgnoreFilename {
+			continue
+		}
+		relPath, err := filepath.Rel(dir, fi.Path)
+		if err != nil || ignore.ignores(filepath.ToSlash(relPath)) {
+			continue
+		}
+		if filters != nil && !filters.matches(dir, fi) {
+			continue
+		}
+		selected = append(selected, fi)
+	}
+	return selected, nil
+}
+
+// readSrcDirs reads all the given source directories, as readSrcDir does,
+// returning their files and the source IDs of the files by path.
+func readSrcDirs(src *ConfigSrc) ([]*fs.FileInfo, map[string]int, error) {
+	var fileInfos []*fs.FileInfo
+	srcIDs := make(map[string]int)
+	for id, d := range src.srcDirs() {
+		dirFileInfos, err := readSrcDir(d.Dir, d.IncludeSubdirs, src.Filters, 0)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, fi := range dirFileInfos {
+			srcIDs[fi.Path] = id
+		}
+		fileInfos = append(fileInfos, dirFileInfos...)
+	}
+	return fileInfos, srcIDs, nil
+}
+
+// matches returns true if the given file, found in srcDir, is selected by the filters.
+func (f *SrcFilters) matches(srcDir string, fi *fs.FileInfo) bool {
+	relPath, err := filepath.Rel(srcDir, fi.Path)
+	if err != nil {
+		return false
+	}
+	relPath = filepath.ToSlash(relPath)
+
+	if f.SkipHidden && isHiddenPath(relPath) {
+		return false
+	}
+	if len(f.Include) > 0 && !matchesAnyPattern(f.Include, fi.Name, relPath) {
+		return false
+	}
+	if matchesAnyPattern(f.Exclude, fi.Name, relPath) {
+		return false
+	}
+	if len(f.Extensions) > 0 && !hasAnyExtension(f.Extensions, fi.Ext) {
+		return false
+	}
+	if f.MinSize > 0 && fi.Size < f.MinSize {
+		return false
+	}
+	if f.MaxSize > 0 && fi.Size > f.MaxSize {
+		return false
+	}
+	if f.MtimeFrom != "" || f.MtimeTo != "" {
+		info, err := os.Stat(fi.Path)
+		if err != nil || !f.matchesMtime(info.ModTime()) {
+			return false
+		}
+	}
+	return true
+}
+
+// matchesMtime returns true if the given modification time is within the filters' dates.
+func (f *SrcFilters) matchesMtime(mtime time.Time) bool {
+	from, to, err := f.mtimeRange()
+	if err != nil {
+		return false
+	}
+	if !from.IsZero() && mtime.Before(from) {
+		return false
+	}
+	if !to.IsZero() && !mtime.Before(to) {
+		return false
+	}
+	return true
+}
+
+// mtimeRange returns the start of MtimeFrom and the end of MtimeTo,
+// which are zero if the corresponding date is empty.
+func (f *SrcFilters) mtimeRange() (time.Time, time.Time, error) {
+	var from, to time.Time
+	var err error
+	if f.MtimeFrom != "" {
+		from, err = time.ParseInLocation(srcFiltersDateLayout, f.MtimeFrom, time.Local)
+		if err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+	}
+	if f.MtimeTo != "" {
+		to, err = time.ParseInLocation(srcFiltersDateLayout, f.MtimeTo, time.Local)
+		if err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+		to = to.AddDate(0, 0, 1)
+	}
+	return from, to, nil
+}
+
+func matchesAnyPattern(patterns []string, name, relPath string) bool {
+	for _, pattern := range patterns {
+		target := name
+		if strings.Contains(pattern, "/") {
+			target = relPath
+		}
+		if ok, _ := filepath.Match(pattern, target); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func hasAnyExtension(extensions []string, ext string) bool {
+	for _, e := range extensions {
+		if strings.EqualFold(strings.TrimPrefix(e, "."), strings.TrimPrefix(ext, ".")) {
+			return true
+		}
+	}
+	return false
+}
+
+// isHiddenPath returns true if any element of the given slash-separated path starts with a dot.
+func isHiddenPath(relPath string) bool {
+	for _, elem := range strings.Split(relPath, "/") {
+		if strings.HasPrefix(elem, ".") && elem != "." && elem != ".." {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,69 @@
+package core
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_readSrcDir(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "dir")
+	assert.Nil(err)
+	defer os.RemoveAll(dir)
+
+	files := []struct {
+		path  string
+		size  int
+		mtime time.Time
+	}{
+		{"a.jpg", 10, time.Date(2024, 3, 1, 10, 0, 0, 0, time.Local)},
+		{"b.PNG", 100, time.Date(2024, 3, 15, 23, 0, 0, 0, time.Local)},
+		{"c.txt", 1000, time.Date(2024, 4, 1, 10, 0, 0, 0, time.Local)},
+		{".hidden.jpg", 10, time.Date(2024, 3, 1, 10, 0, 0, 0, time.Local)},
+		{"raw/d.jpg", 10, time.Date(2024, 3, 1, 10, 0, 0, 0, time.Local)},
+		{".cache/e.jpg", 10, time.Date(2024, 3, 1, 10, 0, 0, 0, time.Local)},
+	}
+	for _, f := range files {
+		path := filepath.Join(dir, filepath.FromSlash(f.path))
+		err := os.MkdirAll(filepath.Dir(path), 0755)
+		assert.Nil(err)
+		err = ioutil.WriteFile(path, make([]byte, f.size), 0644)
+		assert.Nil(err)
+		err = os.Chtimes(path, f.mtime, f.mtime)
+		assert.Nil(err)
+	}
+
+	tests := []struct {
+		name     string
+		filters  *SrcFilters
+		maxFiles int
+		want     []string
+	}{
+		{"no filters", nil, 0, []string{".cache/e.jpg", ".hidden.jpg", "a.jpg", "b.PNG", "c.txt", "raw/d.jpg"}},
+		{"max files", &SrcFilters{}, 2, []string{".cache/e.jpg", ".hidden.jpg"}},
+		{"skip hidden", &SrcFilters{SkipHidden: true}, 0, []string{"a.jpg", "b.PNG", "c.txt", "raw/d.jpg"}},
+		{"include", &SrcFilters{Include: []string{"*.jpg"}, SkipHidden: true}, 0, []string{"a.jpg", "raw/d.jpg"}},
+		{"exclude path", &SrcFilters{Exclude: []string{"raw/*", ".*/*"}}, 0, []string{".hidden.jpg", "a.jpg", "b.PNG", "c.txt"}},
+		{"extensions", &SrcFilters{Extensions: []string{"png", ".txt"}}, 0, []string{"b.PNG", "c.txt"}},
+		{"size", &SrcFilters{MinSize: 50, MaxSize: 500}, 0, []string{"b.PNG"}},
+		{"mtime", &SrcFilters{MtimeFrom: "2024-03-15", MtimeTo: "2024-03-15"}, 0, []string{"b.PNG"}},
+		{"mtime from", &SrcFilters{MtimeFrom: "2024-03-02"}, 0, []string{"b.PNG", "c.txt"}},
+	}
+	for _, tt := range tests {
+		fileInfos, err := readSrcDir(dir, true, tt.filters, tt.maxFiles)
+		assert.Nil(err, tt.name)
+		got := []string{}
+		for _, fi := range fileInfos {
+			rel, err := filepath.Rel(dir, fi.Path)
+			assert.Nil(err, tt.name)
+			got = append(got, filepath.ToSlash(rel))
+		}
+		assert.Equal(tt.want, got, tt.name)
+	}
+}
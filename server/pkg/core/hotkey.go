@@ -0,0 +1,37 @@
+package core
+
+import "strings"
+
+// hotkeyShiftPrefix is the prefix of hotkeys pressed while holding the shift key.
+const hotkeyShiftPrefix = "Shift+"
+
+// HotkeyEvent represents a pressed hotkey together with the modifier keys held.
+// Key is the unmodified key, while Raw, if known, is the character
+// actually typed, for example "A" or "!" when the shift key is held.
+type HotkeyEvent struct {
+	Key   string `json:"key"`
+	Shift bool   `json:"shift"`
+	Raw   string `json:"raw"`
+}
+
+// parseHotkey parses a hotkey in the format used by configurations,
+// that is a key optionally preceded by modifiers, for example "a" or "Shift+a".
+func parseHotkey(hotkey string) *HotkeyEvent {
+	event := &HotkeyEvent{
+		Key: hotkey,
+	}
+	hasShift := strings.HasPrefix(hotkey, hotkeyShiftPrefix) && len(hotkey) > len(hotkeyShiftPrefix)
+	if hasShift {
+		event.Shift = true
+		event.Key = strings.TrimPrefix(hotkey, hotkeyShiftPrefix)
+	}
+	return event
+}
+
+// String returns the hotkey in the format used by configurations.
+func (e *HotkeyEvent) String() string {
+	if e.Shift {
+		return hotkeyShiftPrefix + e.Key
+	}
+	return e.Key
+}
@@ -0,0 +1,33 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_parseHotkey(t *testing.T) {
+	assert := assert.New(t)
+
+	tests := []struct {
+		name   string
+		hotkey string
+		want   *HotkeyEvent
+	}{
+		{"empty hotkey", "", &HotkeyEvent{Key: ""}},
+		{"key", "a", &HotkeyEvent{Key: "a"}},
+		{"space", " ", &HotkeyEvent{Key: " "}},
+		{"shift and key", "Shift+a", &HotkeyEvent{Key: "a", Shift: true}},
+		{"shift and plus", "Shift++", &HotkeyEvent{Key: "+", Shift: true}},
+		{"shift and space", "Shift+ ", &HotkeyEvent{Key: " ", Shift: true}},
+		{"shift only", "Shift+", &HotkeyEvent{Key: "Shift+"}},
+		{"shift and long key", "Shift+ab", &HotkeyEvent{Key: "ab", Shift: true}},
+	}
+	for _, tt := range tests {
+		got := parseHotkey(tt.hotkey)
+		assert.Equal(tt.want, got, tt.name)
+		if got.Key != "Shift+" {
+			assert.Equal(tt.hotkey, got.String(), tt.name)
+		}
+	}
+}
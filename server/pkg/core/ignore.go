@@ -0,0 +1,188 @@
+package core
+
+import (
+	"bufio"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/velut/fsutils-go/fs"
+)
+
+// teclaIgnoreFilename is the name of the files listing, with the gitignore syntax,
+// the files that must not be organized.
+const teclaIgnoreFilename = ".teclaignore"
+
+// ignoreRule represents a line of an ignore file.
+type ignoreRule struct {
+	// base is the slash-separated directory of the ignore file,
+	// relative to the source directory, or "" for the source directory.
+	base string
+	// segments are the slash-separated elements of the pattern.
+	segments []string
+	// negate is true for patterns starting with "!", re-including files.
+	negate bool
+	// dirOnly is true for patterns ending with "/", matching only directories.
+	dirOnly bool
+	// anchored is true for patterns containing a slash before their end,
+	// matched against the whole path relative to base instead of the name.
+	anchored bool
+}
+
+// ignoreRules represents the rules read from the ignore files in a source directory,
+// ordered from the shallowest to the deepest ignore file.
+type ignoreRules []*ignoreRule
+
+// readIgnoreRules reads the rules of the ignore files among the given files,
+// found in srcDir.
+func readIgnoreRules(srcDir string, fileInfos []*fs.FileInfo) (ignoreRules, error) {
+	var ignoreFiles []string
+	for _, fi := range fileInfos {
+		if fi.Name == teclaIgnoreFilename {
+			ignoreFiles = append(ignoreFiles, fi.Path)
+		}
+	}
+	sort.SliceStable(ignoreFiles, func(i, j int) bool {
+		return strings.Count(ignoreFiles[i], string(filepath.Separator)) <
+			strings.Count(ignoreFiles[j], string(filepath.Separator))
+	})
+
+	var rules ignoreRules
+	for _, p := range ignoreFiles {
+		base, err := filepath.Rel(srcDir, filepath.Dir(p))
+		if err != nil {
+			return nil, err
+		}
+		base = filepath.ToSlash(base)
+		if base == "." {
+			base = ""
+		}
+		fileRules, err := readIgnoreFile(p, base)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, fileRules...)
+	}
+	return rules, nil
+}
+
+func readIgnoreFile(path, base string) ([]*ignoreRule, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var rules []*ignoreRule
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if rule := parseIgnoreRule(scanner.Text(), base); rule != nil {
+			rules = append(rules, rule)
+		}
+	}
+	return rules, scanner.Err()
+}
+
+// parseIgnoreRule parses a line of an ignore file with the gitignore syntax,
+// returning nil for blank lines and comments.
+func parseIgnoreRule(line, base string) *ignoreRule {
+	line = strings.TrimSuffix(line, "\r")
+	if strings.HasPrefix(line, "#") {
+		return nil
+	}
+	// Trailing spaces are ignored unless escaped.
+	for strings.HasSuffix(line, " ") && !strings.HasSuffix(line, "\\ ") {
+		line = strings.TrimSuffix(line, " ")
+	}
+	if line == "" {
+		return nil
+	}
+
+	rule := &ignoreRule{base: base}
+	if strings.HasPrefix(line, "!") {
+		rule.negate = true
+		line = line[1:]
+	} else if strings.HasPrefix(line, "\\!") || strings.HasPrefix(line, "\\#") {
+		line = line[1:]
+	}
+	if strings.HasSuffix(line, "/") {
+		rule.dirOnly = true
+		line = strings.TrimSuffix(line, "/")
+	}
+	rule.anchored = strings.Contains(line, "/")
+	line = strings.TrimPrefix(line, "/")
+	if line == "" {
+		return nil
+	}
+	rule.segments = strings.Split(line, "/")
+	return rule
+}
+
+// ignores returns true if the file at the given slash-separated path,
+// relative to the source directory, is ignored by the rules,
+// either directly or because one of its parent directories is ignored.
+func (r ignoreRules) ignores(relPath string) bool {
+	if len(r) == 0 {
+		return false
+	}
+	elems := strings.Split(relPath, "/")
+	for i := 1; i < len(elems); i++ {
+		if r.matches(path.Join(elems[:i]...), true) {
+			return true
+		}
+	}
+	return r.matches(relPath, false)
+}
+
+// matches returns true if the last rule matching the given path ignores it.
+func (r ignoreRules) matches(relPath string, isDir bool) bool {
+	ignored := false
+	for _, rule := range r {
+		if rule.matches(relPath, isDir) {
+			ignored = !rule.negate
+		}
+	}
+	return ignored
+}
+
+func (rule *ignoreRule) matches(relPath string, isDir bool) bool {
+	if rule.dirOnly && !isDir {
+		return false
+	}
+	if rule.base != "" {
+		if !strings.HasPrefix(relPath, rule.base+"/") {
+			return false
+		}
+		relPath = strings.TrimPrefix(relPath, rule.base+"/")
+	}
+	elems := strings.Split(relPath, "/")
+	if !rule.anchored {
+		elems = elems[len(elems)-1:]
+	}
+	return matchSegments(rule.segments, elems)
+}
+
+// matchSegments matches the path elements against the pattern segments,
+// where a "**" segment matches zero or more elements.
+func matchSegments(segments, elems []string) bool {
+	if len(segments) == 0 {
+		return len(elems) == 0
+	}
+	if segments[0] == "**" {
+		for i := 0; i <= len(elems); i++ {
+			if matchSegments(segments[1:], elems[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(elems) == 0 {
+		return false
+	}
+	if ok, _ := path.Match(segments[0], elems[0]); !ok {
+		return false
+	}
+	return matchSegments(segments[1:], elems[1:])
+}
@@ -0,0 +1,102 @@
+package core
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ignoreRules_ignores(t *testing.T) {
+	assert := assert.New(t)
+
+	parse := func(base string, lines ...string) ignoreRules {
+		var rules ignoreRules
+		for _, l := range lines {
+			if rule := parseIgnoreRule(l, base); rule != nil {
+				rules = append(rules, rule)
+			}
+		}
+		return rules
+	}
+
+	tests := []struct {
+		name    string
+		rules   ignoreRules
+		relPath string
+		want    bool
+	}{
+		{"no rules", nil, "a.txt", false},
+		{"comment", parse("", "# a.txt", ""), "a.txt", false},
+		{"name at any level", parse("", "*.tmp"), "x/y/a.tmp", true},
+		{"name not matching", parse("", "*.tmp"), "a.txt", false},
+		{"negation", parse("", "*.tmp", "!keep.tmp"), "x/keep.tmp", false},
+		{"negation overridden", parse("", "!keep.tmp", "*.tmp"), "keep.tmp", true},
+		{"escaped", parse("", "\\#a"), "#a", true},
+		{"directory", parse("", "build/"), "x/build/a.txt", true},
+		{"directory only", parse("", "build/"), "build", false},
+		{"anchored", parse("", "/a.txt"), "x/a.txt", false},
+		{"anchored root", parse("", "/a.txt"), "a.txt", true},
+		{"anchored path", parse("", "x/*.txt"), "x/a.txt", true},
+		{"anchored path deeper", parse("", "x/*.txt"), "y/x/a.txt", false},
+		{"double star", parse("", "**/raw/*.jpg"), "x/y/raw/a.jpg", true},
+		{"double star inside", parse("", "x/**/a.jpg"), "x/a.jpg", true},
+		{"trailing double star", parse("", "x/**"), "x/y/a.jpg", true},
+		{"parent directory ignored", parse("", "x/", "!x/a.txt"), "x/a.txt", true},
+		{"subdirectory rules", parse("sub", "*.txt"), "a.txt", false},
+		{"subdirectory rules match", parse("sub", "/a.txt"), "sub/a.txt", true},
+		{"deeper rules win", append(parse("", "*.txt"), parse("sub", "!a.txt")...), "sub/a.txt", false},
+	}
+	for _, tt := range tests {
+		assert.Equal(tt.want, tt.rules.ignores(tt.relPath), tt.name)
+	}
+}
+
+func Test_readSrcDirIgnore(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "dir")
+	assert.Nil(err)
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		teclaIgnoreFilename:                  "*.tmp\nbuild/\n",
+		"a.txt":                              "",
+		"a.tmp":                              "",
+		"build/b.txt":                        "",
+		"sub/" + teclaIgnoreFilename:         "*.txt\n!keep.tmp\n",
+		"sub/c.txt":                          "",
+		"sub/d.jpg":                          "",
+		"sub/keep.tmp":                       "",
+		"other/" + teclaIgnoreFilename + "x": "",
+	}
+	for p, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(p))
+		err := os.MkdirAll(filepath.Dir(path), 0755)
+		assert.Nil(err)
+		err = ioutil.WriteFile(path, []byte(content), 0644)
+		assert.Nil(err)
+	}
+
+	tests := []struct {
+		name           string
+		includeSubdirs bool
+		want           []string
+	}{
+		{"root only", false, []string{"a.txt"}},
+		{"subdirectories", true, []string{"a.txt", "other/" + teclaIgnoreFilename + "x", "sub/d.jpg", "sub/keep.tmp"}},
+	}
+	for _, tt := range tests {
+		fileInfos, err := readSrcDir(dir, tt.includeSubdirs, nil, 0)
+		assert.Nil(err, tt.name)
+		got := []string{}
+		for _, fi := range fileInfos {
+			rel, err := filepath.Rel(dir, fi.Path)
+			assert.Nil(err, tt.name)
+			got = append(got, filepath.ToSlash(rel))
+		}
+		assert.Equal(tt.want, got, tt.name)
+	}
+}
@@ -0,0 +1,235 @@
+package core
+
+import (
+	"bufio"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+const defaultJournalFilename = "tecla-journal.jsonl"
+
+// Journal represents an append-only log, stored as JSON lines,
+// of the operations submitted to the organizer and of their completion.
+// The journal allows to detect operations left unfinished
+// when the application is closed or crashes.
+type Journal struct {
+	mutex sync.Mutex
+	path  string
+}
+
+// journalEntry represents a line of the journal.
+type journalEntry struct {
+	Type    journalEntryType `json:"type"`
+	Session int64            `json:"session"`
+	OpID    int64            `json:"opId"`
+	Op      *Operation       `json:"op,omitempty"`
+	Status  OpStatus         `json:"status,omitempty"`
+}
+
+// journalEntryType enum type.
+type journalEntryType string
+
+// journalEntryType enum values.
+const (
+	journalEntrySubmit   journalEntryType = "submit"
+	journalEntryComplete journalEntryType = "complete"
+	journalEntryDiscard  journalEntryType = "discard"
+)
+
+// journalKey identifies an operation across organizer sessions.
+type journalKey struct {
+	session int64
+	opID    int64
+}
+
+// NewJournal returns a new Journal stored in the file at the given path.
+func NewJournal(path string) *Journal {
+	return &Journal{
+		path: path,
+	}
+}
+
+// DefaultJournal returns the Journal stored in the user's tecla configuration directory.
+func DefaultJournal() *Journal {
+	dir, err := teclaConfigDir()
+	if err != nil {
+		return NewJournal("")
+	}
+	return NewJournal(filepath.Join(dir, defaultJournalFilename))
+}
+
+func (j *Journal) submit(session int64, op *Operation) error {
+	return j.append(&journalEntry{
+		Type:    journalEntrySubmit,
+		Session: session,
+		OpID:    op.ID,
+		Op:      op,
+	})
+}
+
+func (j *Journal) complete(session int64, op *Operation) error {
+	return j.append(&journalEntry{
+		Type:    journalEntryComplete,
+		Session: session,
+		OpID:    op.ID,
+		Status:  op.Status,
+	})
+}
+
+func (j *Journal) discard(key journalKey) error {
+	return j.append(&journalEntry{
+		Type:    journalEntryDiscard,
+		Session: key.session,
+		OpID:    key.opID,
+	})
+}
+
+func (j *Journal) append(entry *journalEntry) error {
+	if j.path == "" {
+		return nil
+	}
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(j.path), 0700); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(j.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// unfinished returns the operations submitted and never completed
+// in sessions other than the given one, in submission order.
+func (j *Journal) unfinished(currentSession int64) ([]journalKey, map[journalKey]*Operation, error) {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	entries, err := j.read()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	keys, ops := unfinishedEntries(entries, currentSession)
+	return keys, ops, nil
+}
+
+func unfinishedEntries(entries []*journalEntry, currentSession int64) ([]journalKey, map[journalKey]*Operation) {
+	ops := make(map[journalKey]*Operation)
+	seen := make(map[journalKey]bool)
+	var keys []journalKey
+	for _, e := range entries {
+		key := journalKey{e.Session, e.OpID}
+		switch e.Type {
+		case journalEntrySubmit:
+			if e.Op == nil || e.Session == currentSession {
+				continue
+			}
+			// An operation retried after completing is submitted again with the same key.
+			if !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+			ops[key] = e.Op
+		case journalEntryComplete, journalEntryDiscard:
+			delete(ops, key)
+		}
+	}
+
+	var pendingKeys []journalKey
+	for _, key := range keys {
+		if _, ok := ops[key]; ok {
+			pendingKeys = append(pendingKeys, key)
+		}
+	}
+	return pendingKeys, ops
+}
+
+// read returns the entries in the journal.
+// Lines that cannot be decoded, for example a line truncated by a crash, are ignored.
+// The caller must hold mutex.
+func (j *Journal) read() ([]*journalEntry, error) {
+	if j.path == "" {
+		return nil, nil
+	}
+
+	f, err := os.Open(j.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries []*journalEntry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		entry := &journalEntry{}
+		if err := json.Unmarshal(scanner.Bytes(), entry); err != nil {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	return entries, scanner.Err()
+}
+
+// compact rewrites the journal keeping only the submissions of unfinished operations.
+func (j *Journal) compact() error {
+	if j.path == "" {
+		return nil
+	}
+
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	entries, err := j.read()
+	if err != nil {
+		return err
+	}
+
+	var data []byte
+	keys, ops := unfinishedEntries(entries, 0)
+	for _, key := range keys {
+		line, err := json.Marshal(&journalEntry{
+			Type:    journalEntrySubmit,
+			Session: key.session,
+			OpID:    key.opID,
+			Op:      ops[key],
+		})
+		if err != nil {
+			return err
+		}
+		data = append(data, line...)
+		data = append(data, '\n')
+	}
+
+	if err := os.MkdirAll(filepath.Dir(j.path), 0700); err != nil {
+		return err
+	}
+	tmpPath := j.path + ".tmp"
+	if err := ioutil.WriteFile(tmpPath, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, j.path)
+}
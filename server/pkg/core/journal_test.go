@@ -0,0 +1,78 @@
+package core
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJournal(t *testing.T) {
+	assert := assert.New(t)
+	name := "TestJournal"
+
+	dir, err := ioutil.TempDir("", "journal")
+	assert.Nil(err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "tecla", defaultJournalFilename)
+
+	j := NewJournal(path)
+
+	// Empty journal
+	keys, _, err := j.unfinished(0)
+	assert.Nil(err, name)
+	assert.Empty(keys, name)
+
+	op1 := &Operation{ID: 1, Op: OpTypeMove, SrcPath: "a", DstPath: "b", MaxTries: 1}
+	op2 := &Operation{ID: 2, Op: OpTypeCopy, SrcPath: "c", DstPath: "d", MaxTries: 1}
+	op3 := &Operation{ID: 1, Op: OpTypeCopy, SrcPath: "e", DstPath: "f", MaxTries: 1}
+	assert.Nil(j.submit(10, op1), name)
+	assert.Nil(j.submit(10, op2), name)
+	assert.Nil(j.submit(20, op3), name)
+	op1.Status = OpStatusSucceeded
+	assert.Nil(j.complete(10, op1), name)
+
+	// Truncated line left by a crash
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0644)
+	assert.Nil(err)
+	_, err = f.WriteString(`{"type":"complete","sess`)
+	assert.Nil(err)
+	assert.Nil(f.Close())
+
+	// Current session is excluded
+	keys, ops, err := j.unfinished(20)
+	assert.Nil(err, name)
+	assert.Equal([]journalKey{{10, 2}}, keys, name)
+	assert.Equal("c", ops[keys[0]].SrcPath, name)
+
+	keys, ops, err = j.unfinished(0)
+	assert.Nil(err, name)
+	assert.Equal([]journalKey{{10, 2}, {20, 1}}, keys, name)
+	assert.Equal("e", ops[keys[1]].SrcPath, name)
+
+	// Compaction keeps unfinished operations only
+	assert.Nil(j.compact(), name)
+	entries, err := j.read()
+	assert.Nil(err, name)
+	assert.Len(entries, 2, name)
+
+	// Discarded operations are finished
+	assert.Nil(j.discard(journalKey{10, 2}), name)
+	keys, _, err = j.unfinished(0)
+	assert.Nil(err, name)
+	assert.Equal([]journalKey{{20, 1}}, keys, name)
+
+	// Operations retried after failing are reported once
+	op4 := &Operation{ID: 1, Op: OpTypeMove, SrcPath: "g", DstPath: "h", MaxTries: 1}
+	assert.Nil(j.submit(30, op4), name)
+	op4.Status = OpStatusFailed
+	assert.Nil(j.complete(30, op4), name)
+	op4.Status = OpStatusQueued
+	assert.Nil(j.submit(30, op4), name)
+	keys, ops, err = j.unfinished(0)
+	assert.Nil(err, name)
+	assert.Equal([]journalKey{{20, 1}, {30, 1}}, keys, name)
+	assert.Equal("g", ops[keys[1]].SrcPath, name)
+}
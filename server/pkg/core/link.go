@@ -0,0 +1,33 @@
+package core
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// linkFile creates a link of the given type at dstPath pointing to the file at srcPath.
+// Symbolic links point to the absolute path of the file.
+// If dstPath already exists, linkFile returns an error satisfying os.IsExist.
+func linkFile(opType OpType, srcPath, dstPath string) error {
+	switch opType {
+	case OpTypeSymlink:
+		absSrcPath, err := filepath.Abs(srcPath)
+		if err != nil {
+			return err
+		}
+		return os.Symlink(absSrcPath, dstPath)
+	case OpTypeHardlink:
+		return os.Link(srcPath, dstPath)
+	default:
+		return fmt.Errorf("operation type %q is not a link", opType)
+	}
+}
+
+// relinkFile is like linkFile, but replaces the file existing at dstPath, if any.
+func relinkFile(opType OpType, srcPath, dstPath string) error {
+	if err := os.Remove(dstPath); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return linkFile(opType, srcPath, dstPath)
+}
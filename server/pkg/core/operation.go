@@ -2,27 +2,83 @@ package core
 
 // Operation represents an organizer's operation.
 type Operation struct {
-	ID       int64  `json:"id"`
-	Op       OpType `json:"op"`
-	SrcPath  string `json:"srcPath"`
-	DstPath  string `json:"dstPath"`
-	MaxTries int    `json:"maxTries"`
+	ID              int64           `json:"id"`
+	FileID          int64           `json:"fileId"`
+	Op              OpType          `json:"op"`
+	SrcPath         string          `json:"srcPath"`
+	DstPath         string          `json:"dstPath"`
+	MaxTries        int             `json:"maxTries"`
+	CollisionPolicy CollisionPolicy `json:"collisionPolicy"`
+	Status          OpStatus        `json:"status"`
+	FinalDstPath    string          `json:"finalDstPath"`
+	Outcome         OpOutcome       `json:"outcome"`
+	Err             string          `json:"err"`
+
+	done chan struct{}
+	// after lists the operations that must finish before this one starts.
+	after []*Operation
+	// restored is true for operations executed in a previous session,
+	// kept only as a record of the decision taken on their file.
+	restored bool
+	// dstRelPath is the destination relative to the destination directory,
+	// with its rendered subpath and name, before resolving collisions.
+	dstRelPath string
+	// counterHotkey and counter identify the value of the rename template's counter
+	// used for the destination, held by the operation while counterHeld is true.
+	counterHotkey string
+	counter       int
+	counterHeld   bool
 }
 
+// OpStatus enum type.
+type OpStatus string
+
+// OpStatus enum values.
+const (
+	OpStatusStaged    OpStatus = "staged"
+	OpStatusQueued    OpStatus = "queued"
+	OpStatusRunning   OpStatus = "running"
+	OpStatusSucceeded OpStatus = "succeeded"
+	OpStatusFailed    OpStatus = "failed"
+	OpStatusCanceled  OpStatus = "canceled"
+	OpStatusReverted  OpStatus = "reverted"
+	OpStatusDismissed OpStatus = "dismissed"
+)
+
 // OpType enum type.
 type OpType string
 
 // OpType enum values.
 const (
-	OpTypeCopy OpType = "copy"
-	OpTypeMove OpType = "move"
+	OpTypeCopy     OpType = "copy"
+	OpTypeMove     OpType = "move"
+	OpTypeTrash    OpType = "trash"
+	OpTypeSymlink  OpType = "symlink"
+	OpTypeHardlink OpType = "hardlink"
 )
 
 // IsValid returns true if the OpType value belongs to the enum.
 func (t OpType) IsValid() bool {
 	valid := map[OpType]bool{
-		OpTypeCopy: true,
-		OpTypeMove: true,
+		OpTypeCopy:     true,
+		OpTypeMove:     true,
+		OpTypeTrash:    true,
+		OpTypeSymlink:  true,
+		OpTypeHardlink: true,
 	}
 	return valid[t]
 }
+
+// Inverted returns the opposite OpType for copy and move operations,
+// that is move for copy and copy for move.
+// Other operation types are returned unchanged.
+func (t OpType) Inverted() OpType {
+	switch t {
+	case OpTypeCopy:
+		return OpTypeMove
+	case OpTypeMove:
+		return OpTypeCopy
+	default:
+		return t
+	}
+}
@@ -39,6 +39,21 @@ func TestOpType_IsValid(t *testing.T) {
 			OpTypeMove,
 			true,
 		},
+		{
+			"valid op type trash",
+			OpTypeTrash,
+			true,
+		},
+		{
+			"valid op type symlink",
+			OpTypeSymlink,
+			true,
+		},
+		{
+			"valid op type hardlink",
+			OpTypeHardlink,
+			true,
+		},
 	}
 	for _, tt := range tests {
 		got := OpType.IsValid(tt.t)
@@ -7,17 +7,25 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
 	"time"
+	"unicode/utf8"
 
+	"github.com/fsnotify/fsnotify"
 	"github.com/gammazero/workerpool"
 	"github.com/velut/fsutils-go/fs"
 )
 
 // Organizer represents the organizer that handles files and file operations.
 type Organizer struct {
-	mutex     sync.Mutex
-	organizer *organizer
+	mutex       sync.Mutex
+	organizer   *organizer
+	journal     *Journal
+	sessionPath string
+	// sessionSettled is true once the session left by a previous run,
+	// if any, has been resumed or discarded and can be overwritten.
+	sessionSettled bool
 }
 
 type organizer struct {
@@ -26,6 +34,33 @@ type organizer struct {
 	currentFileIndex int
 	fileServer       *FileServer
 	workerPool       *workerpool.WorkerPool
+	decisions        []*decision
+	operations       []*Operation
+	lastOperationID  int64
+	opsMutex         sync.Mutex
+	journal          *Journal
+	session          int64
+	// counters maps destination directories' hotkeys
+	// to the number of files sent with their templates.
+	counters map[string]int
+	// sortSeed is the seed of the random order of files,
+	// either configured or generated for the session.
+	sortSeed int64
+	// freeCounters maps destination directories' hotkeys to the values
+	// below their counters released by canceled or reverted operations.
+	freeCounters map[string][]int
+	watcher      *fsnotify.Watcher
+	// numAddedFiles is the number of files added to the source directory
+	// after the configuration was loaded.
+	numAddedFiles int
+}
+
+// decision represents a choice made by the user on a file,
+// that is skipping it or sending it to a destination directory.
+type decision struct {
+	fileIndex int
+	ops       []*Operation
+	replaced  []*Operation
 }
 
 // Files represents a collection of files.
@@ -33,21 +68,56 @@ type Files []*File
 
 // OrganizerStatus represents the status of the organizer.
 type OrganizerStatus struct {
-	Config           *Config `json:"config"`
-	CurrentFile      *File   `json:"currentFile"`
-	CurrentFileIndex int     `json:"currentFileIndex"`
-	NumFiles         int     `json:"numFiles"`
+	Config           *Config           `json:"config"`
+	CurrentFile      *File             `json:"currentFile"`
+	CurrentFileIndex int               `json:"currentFileIndex"`
+	NumFiles         int               `json:"numFiles"`
+	Operations       *OperationsStatus `json:"operations"`
+
+	// CurrentFileOperations lists the operations already submitted for the current file.
+	CurrentFileOperations []*Operation `json:"currentFileOperations"`
+
+	// CurrentFileNotReady, if not empty, explains why the current file
+	// cannot be sent to a destination directory yet; see ConfigSrc.CheckStable.
+	CurrentFileNotReady string `json:"currentFileNotReady"`
+
+	// NumAddedFiles is the number of files found in the source directory
+	// after the configuration was loaded and appended to the files.
+	NumAddedFiles int `json:"numAddedFiles"`
+	// NumGoneFiles is the number of files vanished from the source directory.
+	NumGoneFiles int `json:"numGoneFiles"`
+}
+
+// OperationsStatus represents the status of the operations submitted to the organizer.
+type OperationsStatus struct {
+	NumStaged    int          `json:"numStaged"`
+	NumQueued    int          `json:"numQueued"`
+	NumRunning   int          `json:"numRunning"`
+	NumSucceeded int          `json:"numSucceeded"`
+	NumFailed    int          `json:"numFailed"`
+	Failed       []*Operation `json:"failed"`
 }
 
 // NewOrganizer creates a new Organizer.
 func NewOrganizer() *Organizer {
+	return NewOrganizerWithJournal(DefaultJournal())
+}
+
+// NewOrganizerWithJournal creates a new Organizer recording operations in the given journal.
+func NewOrganizerWithJournal(journal *Journal) *Organizer {
 	return &Organizer{
-		organizer: newOrganizer(),
+		organizer:   newOrganizer(journal),
+		journal:     journal,
+		sessionPath: defaultSessionPath(),
 	}
 }
 
-func newOrganizer() *organizer {
-	return &organizer{}
+func newOrganizer(journal *Journal) *organizer {
+	return &organizer{
+		journal:      journal,
+		counters:     make(map[string]int),
+		freeCounters: make(map[string][]int),
+	}
 }
 
 // RestoreConfig TODO:
@@ -56,12 +126,11 @@ func (o *Organizer) RestoreConfig() (*Config, error) {
 	defer o.mutex.Unlock()
 
 	// Get latest config
-	ucDir, err := os.UserConfigDir()
+	teclaDir, err := teclaConfigDir()
 	if err != nil {
 		return nil, err
 	}
 
-	teclaDir := filepath.Join(ucDir, "tecla")
 	latestFile := filepath.Join(teclaDir, "tecla-latest-config.json")
 	configJSON, err := ioutil.ReadFile(latestFile)
 	if err != nil {
@@ -86,25 +155,116 @@ func (o *Organizer) LoadConfig(config *Config) (*OrganizerStatus, error) {
 		o.dropConfig()
 		return nil, err
 	}
+	o.startWatcher()
 
 	// Save as latest config
 	configJSON, _ := json.Marshal(config)
-	ucDir, _ := os.UserConfigDir()
-	teclaDir := filepath.Join(ucDir, "tecla")
+	teclaDir, _ := teclaConfigDir()
 	_ = os.MkdirAll(teclaDir, 0700)
 	latestFile := filepath.Join(teclaDir, "tecla-latest-config.json")
 	_ = ioutil.WriteFile(latestFile, configJSON, 0644)
 	//
 
+	o.saveSession()
+
+	return o.organizerStatus()
+}
+
+// ResumeSession restores the latest organizing session, starting the organizer.
+// The session's configuration must still be valid.
+// Files already moved away from the source directory are no longer organized,
+// while the other files already decided are placed before the current file.
+func (o *Organizer) ResumeSession() (*OrganizerStatus, error) {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	session, err := readSession(o.sessionPath)
+	if err != nil {
+		return nil, err
+	}
+	if err := NewConfigValidator().ValidateConfig(session.Config); err != nil {
+		return nil, err
+	}
+
+	o.dropConfig()
+	if err := o.resumeSession(session); err != nil {
+		o.dropConfig()
+		return nil, err
+	}
+	o.startWatcher()
+
+	o.sessionSettled = true
+	o.saveSession()
+
 	return o.organizerStatus()
 }
 
+func (o *Organizer) resumeSession(session *Session) error {
+	return o.organizer.resumeSession(session)
+}
+
+func (o *organizer) resumeSession(session *Session) error {
+	o.sortSeed = session.SortSeed
+	if err := o.loadConfig(session.Config); err != nil {
+		return err
+	}
+
+	o.reconcileSession(session)
+
+	return nil
+}
+
+func (o *Organizer) saveSession() {
+	if !o.organizer.hasConfig() {
+		return
+	}
+	// The session left by a previous run is kept until it is resumed or discarded.
+	if !o.sessionSettled {
+		if _, err := os.Stat(o.sessionPath); err == nil {
+			return
+		}
+		o.sessionSettled = true
+	}
+	_ = writeSession(o.sessionPath, o.organizer.sessionState())
+}
+
+// LatestSession returns the latest organizing session, which can be resumed
+// with ResumeSession, or nil if there is none.
+// The latest session is not overwritten by LoadConfig until it is resumed
+// or discarded with DiscardSession.
+func (o *Organizer) LatestSession() (*Session, error) {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	session, err := readSession(o.sessionPath)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	return session, err
+}
+
+// DiscardSession removes the latest organizing session,
+// which is otherwise kept until it is resumed.
+func (o *Organizer) DiscardSession() error {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	if err := os.Remove(o.sessionPath); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	o.sessionSettled = true
+	o.saveSession()
+	return nil
+}
+
 func (o *Organizer) loadConfig(config *Config) error {
 	return o.organizer.loadConfig(config)
 }
 
 func (o *organizer) loadConfig(config *Config) error {
 	o.config = config
+	o.session = time.Now().UnixNano()
+	_ = o.journal.compact()
 
 	if err := o.gatherFiles(); err != nil {
 		return err
@@ -118,12 +278,8 @@ func (o *organizer) loadConfig(config *Config) error {
 
 func (o *organizer) gatherFiles() error {
 	configSrc := o.config.Src
-	srcDir := configSrc.Dir
-	includeSubdirs := configSrc.IncludeSubdirs
 
-	fileInfos, err := fs.ReadDir(srcDir, &fs.ReadDirOptions{
-		IncludeSubdirs: includeSubdirs,
-	})
+	fileInfos, srcIDs, err := readSrcDirs(configSrc)
 	if err != nil {
 		return err
 	}
@@ -131,31 +287,66 @@ func (o *organizer) gatherFiles() error {
 	if noFiles {
 		return errors.New("no files to organize")
 	}
+	if configSrc.SortSeed != 0 {
+		o.sortSeed = configSrc.SortSeed
+	}
+	if configSrc.Sort == SrcSortRandom && o.sortSeed == 0 {
+		o.sortSeed = newSortSeed()
+	}
+	sortFiles(fileInfos, configSrc.Sort, o.sortSeed)
 
 	o.files = make(Files, len(fileInfos))
 	for i, fi := range fileInfos {
-		o.files[i] = &File{
-			ID:   int64(i + 1),
-			Name: fi.Name,
-			Dir:  fi.Dir,
-			Path: fi.Path,
-			Ext:  fi.Ext,
-			Size: fi.Size,
-			URL:  fileURL(srcDir, fi.Path),
-		}
+		o.files[i] = o.newFile(int64(i+1), srcIDs[fi.Path], fi)
 	}
 
 	return nil
 }
 
-func fileURL(srcDir, filePath string) string {
+func (o *organizer) newFile(id int64, srcID int, fi *fs.FileInfo) *File {
+	file := &File{
+		ID:    id,
+		SrcID: srcID,
+		Name:  fi.Name,
+		Dir:   fi.Dir,
+		Path:  fi.Path,
+		Ext:   fi.Ext,
+		Size:  fi.Size,
+		URL:   fileURL(srcID, o.config.Src.srcDirs()[srcID].Dir, fi.Path),
+	}
+	if o.config.Src.CheckStable {
+		_ = observeFile(file)
+	}
+	return file
+}
+
+// srcDirOf returns the source directory containing the given file.
+func (o *organizer) srcDirOf(file *File) *SrcDir {
+	return o.config.Src.srcDirs()[file.SrcID]
+}
+
+func teclaConfigDir() (string, error) {
+	ucDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(ucDir, "tecla"), nil
+}
+
+// fileURL returns the URL of the file at the given path, in the given source directory,
+// on the file server; see NewFileServer.
+func fileURL(srcID int, srcDir, filePath string) string {
 	rel, _ := filepath.Rel(srcDir, filePath)
 	serverPath := filepath.ToSlash(rel)
-	return defaultFileServerAddr + "/" + serverPath
+	return fmt.Sprintf("%s/%d/%s", defaultFileServerAddr, srcID, serverPath)
 }
 
 func (o *organizer) startFileServer() {
-	o.fileServer = NewFileServer(o.config.Src.Dir)
+	var dirs []string
+	for _, d := range o.config.Src.srcDirs() {
+		dirs = append(dirs, d.Dir)
+	}
+	o.fileServer = NewFileServer(dirs...)
 }
 
 func (o *organizer) startWorkerPool() {
@@ -164,21 +355,35 @@ func (o *organizer) startWorkerPool() {
 
 // DropConfigWait removes the current configuration, if any, stopping the organizer.
 // All submitted operations, pending or in progress, are completed.
+// Operations staged in deferred mode are discarded unless committed first with CommitDecisions.
+// The returned status reports the operations of the removed configuration,
+// so that the operations failed while completing them are not lost.
 func (o *Organizer) DropConfigWait() (*OrganizerStatus, error) {
 	o.mutex.Lock()
 	defer o.mutex.Unlock()
 
+	dropped := o.organizer
 	o.dropConfigWait()
 
-	return o.organizerStatus()
+	status, err := o.organizerStatus()
+	if err != nil {
+		return nil, err
+	}
+	if dropped.hasConfig() {
+		status.Operations = dropped.operationsStatus()
+	}
+	return status, nil
 }
 
 func (o *Organizer) dropConfigWait() {
 	o.organizer.stopWait()
-	o.organizer = newOrganizer()
+	// The session records the final status of the completed operations.
+	o.saveSession()
+	o.organizer = newOrganizer(o.journal)
 }
 
 func (o *organizer) stopWait() {
+	o.stopWatcher()
 	o.stopFileServer()
 	o.stopWorkerPoolWait()
 }
@@ -202,10 +407,11 @@ func (o *Organizer) DropConfig() (*OrganizerStatus, error) {
 
 func (o *Organizer) dropConfig() {
 	o.organizer.stop()
-	o.organizer = newOrganizer()
+	o.organizer = newOrganizer(o.journal)
 }
 
 func (o *organizer) stop() {
+	o.stopWatcher()
 	o.stopFileServer()
 	o.stopWorkerPool()
 }
@@ -240,69 +446,372 @@ func (o *organizer) status() (*OrganizerStatus, error) {
 		status.CurrentFile = o.currentFile()
 		status.CurrentFileIndex = o.currentFileIndex
 		status.NumFiles = len(o.files)
+		status.Operations = o.operationsStatus()
+		status.CurrentFileOperations = o.currentFileOperations()
+		if err := o.checkCurrentFileReady(); err != nil {
+			status.CurrentFileNotReady = err.Error()
+		}
+		status.NumAddedFiles = o.numAddedFiles
+		for _, f := range o.files {
+			if f.Gone {
+				status.NumGoneFiles++
+			}
+		}
 	}
 	return status, nil
 }
 
+func (o *organizer) operationsStatus() *OperationsStatus {
+	o.opsMutex.Lock()
+	defer o.opsMutex.Unlock()
+
+	status := &OperationsStatus{
+		Failed: []*Operation{},
+	}
+	for _, op := range o.operations {
+		switch op.Status {
+		case OpStatusStaged:
+			status.NumStaged++
+		case OpStatusQueued:
+			status.NumQueued++
+		case OpStatusRunning:
+			status.NumRunning++
+		case OpStatusSucceeded:
+			status.NumSucceeded++
+		case OpStatusFailed:
+			status.NumFailed++
+			failedOp := *op
+			status.Failed = append(status.Failed, &failedOp)
+		}
+	}
+	return status
+}
+
 // HandleHotkey handles the action corresponding to the pressed hotkey.
 // If the hotkey is the space character, the organizer advances to the next file.
 // If the hotkey is associated to a destination directory, the organizer creates
 // a file operation for the current file and then advances to the next file.
+// If the current file was already sent to a destination directory,
+// the hotkey associated to a destination directory is ignored.
+// In deferred and dry run modes, operations are only staged and the decision
+// on a file can be changed by revisiting it, until CommitDecisions is called.
+// If the hotkey is pressed with the shift modifier, for example "Shift+a",
+// and no destination directory is associated to the modified hotkey,
+// the operation type is inverted, from move to copy or from copy to move,
+// for the current file only.
 // If the hotkey is unrecognized, the organizer does nothing.
 func (o *Organizer) HandleHotkey(hotkey string) (*OrganizerStatus, error) {
+	return o.HandleHotkeyEvent(parseHotkey(hotkey))
+}
+
+// HandleHotkeyEvent handles the action corresponding to the pressed hotkey
+// and modifier keys, like HandleHotkey.
+// If the event reports the typed character, for example "A" or "!",
+// a destination directory associated to that character takes precedence
+// over inverting the operation type of the unmodified key.
+func (o *Organizer) HandleHotkeyEvent(event *HotkeyEvent) (*OrganizerStatus, error) {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	o.handleHotkey(event)
+	o.saveSession()
+
+	return o.organizerStatus()
+}
+
+func (o *Organizer) handleHotkey(event *HotkeyEvent) {
+	o.organizer.handleHotkey(event)
+}
+
+func (o *organizer) handleHotkey(event *HotkeyEvent) {
+	if event == nil {
+		return
+	}
+	o.handleHotkeys([]*HotkeyEvent{event})
+}
+
+// HandleHotkeys sends the current file to all the destination directories
+// associated to the given hotkeys with a single decision, and then advances
+// to the next file.
+// The operation for each destination directory is determined as in HandleHotkey,
+// except that the file is copied instead of moved to all the destination directories
+// but the last one; the last operation is executed only after all the others have finished.
+// If a hotkey is unrecognized or if two hotkeys are associated
+// to the same destination directory, the organizer does nothing.
+func (o *Organizer) HandleHotkeys(hotkeys []string) (*OrganizerStatus, error) {
 	o.mutex.Lock()
 	defer o.mutex.Unlock()
 
-	o.handleHotkey(hotkey)
+	events := make([]*HotkeyEvent, len(hotkeys))
+	for i, hotkey := range hotkeys {
+		events[i] = parseHotkey(hotkey)
+	}
+	o.handleHotkeys(events)
+	o.saveSession()
 
 	return o.organizerStatus()
 }
 
-func (o *Organizer) handleHotkey(hotkey string) {
-	o.organizer.handleHotkey(hotkey)
+func (o *Organizer) handleHotkeys(events []*HotkeyEvent) {
+	o.organizer.handleHotkeys(events)
 }
 
-func (o *organizer) handleHotkey(hotkey string) {
+func (o *organizer) handleHotkeys(events []*HotkeyEvent) {
+	if len(events) == 0 {
+		return
+	}
+
 	noConfig := !o.hasConfig()
 	if noConfig {
 		return
 	}
 
-	skipFile := hotkey == " "
+	if !o.hasCurrentFile() {
+		return
+	}
+
+	skipFile := len(events) == 1 && events[0].Key == " "
 	if skipFile {
+		replaced := o.unstageFileOperations(o.currentFileIndex)
+		o.pushDecision(nil, replaced)
 		o.incrementCurrentFileIndex()
 		return
 	}
 
-	if err := o.submitOperation(hotkey); err != nil {
+	alreadySent := o.hasSubmittedOperations(o.currentFileIndex)
+	if alreadySent {
+		return
+	}
+
+	// Files still being written are left for later.
+	o.observeCurrentFile()
+	if err := o.checkCurrentFileReady(); err != nil {
+		return
+	}
+
+	// Staged operations are replaced before creating the new ones,
+	// so that they no longer reserve their destinations.
+	replaced := o.unstageFileOperations(o.currentFileIndex)
+	ops, err := o.createOperations(events)
+	if err != nil {
+		o.restageOperations(replaced)
 		return
 	}
 
+	for _, op := range ops {
+		o.submitOperation(op)
+	}
+	o.pushDecision(ops, replaced)
 	o.incrementCurrentFileIndex()
 }
 
-func (o *organizer) submitOperation(hotkey string) error {
-	op, err := o.createOperation(hotkey)
-	if err != nil {
-		return err
+// pushDecision records a decision on the current file.
+// Replaced operations are staged operations discarded by the decision,
+// which are staged again if the decision is undone.
+func (o *organizer) pushDecision(ops, replaced []*Operation) {
+	o.decisions = append(o.decisions, &decision{
+		fileIndex: o.currentFileIndex,
+		ops:       ops,
+		replaced:  replaced,
+	})
+}
+
+// submitOperation adds the given operation to the organizer's operations.
+// In deferred mode the operation is staged until CommitDecisions is called,
+// in dry run mode the operation is staged and never executed,
+// otherwise it is immediately submitted to the worker pool.
+func (o *organizer) submitOperation(op *Operation) {
+	o.opsMutex.Lock()
+	o.operations = append(o.operations, op)
+	deferred := o.config.Ops.Deferred || o.config.Ops.DryRun
+	if deferred {
+		op.Status = OpStatusStaged
+	}
+	o.opsMutex.Unlock()
+
+	if !deferred {
+		o.enqueueOperation(op)
 	}
+}
 
+func (o *organizer) enqueueOperation(op *Operation) {
+	_ = o.journal.submit(o.session, op)
+
+	// In deferred mode, operations are executed only after all decisions
+	// have been taken, so they never involve files displayed in the gui.
+	slowDown := !o.config.Ops.Deferred
 	o.workerPool.Submit(func() {
 		// Slow down workers. If they are too fast,
 		// they may try to access files still displayed in the gui;
 		// this causes problems when trying to remove files
 		// currently being served by the fileserver.
-		time.Sleep(250 * time.Millisecond)
-		o.executeOperation(op)
+		if slowDown {
+			time.Sleep(250 * time.Millisecond)
+		}
+		o.runOperation(op)
 	})
+}
+
+// hasSubmittedOperations returns true if the file at the given index
+// has operations that are not staged, canceled or reverted.
+func (o *organizer) hasSubmittedOperations(fileIndex int) bool {
+	for _, op := range o.fileOperations(fileIndex) {
+		if op.Status != OpStatusStaged {
+			return true
+		}
+	}
+	return false
+}
+
+// unstageFileOperations cancels the staged operations of the file at the given index
+// and returns them.
+func (o *organizer) unstageFileOperations(fileIndex int) []*Operation {
+	o.opsMutex.Lock()
+	defer o.opsMutex.Unlock()
+
+	var unstaged []*Operation
+	for _, d := range o.decisions {
+		if d.fileIndex != fileIndex {
+			continue
+		}
+		for _, op := range d.ops {
+			if op.Status == OpStatusStaged {
+				op.Status = OpStatusCanceled
+				unstaged = append(unstaged, op)
+			}
+		}
+	}
+	o.releaseCounters(unstaged)
+	return unstaged
+}
+
+// restageOperations stages again the given operations, previously unstaged.
+func (o *organizer) restageOperations(ops []*Operation) {
+	o.opsMutex.Lock()
+	defer o.opsMutex.Unlock()
+
+	for _, op := range ops {
+		if op.Status == OpStatusCanceled {
+			op.Status = OpStatusStaged
+			o.takeCounter(op)
+		}
+	}
+}
+
+// CommitDecisions submits to the worker pool all the operations
+// staged in deferred mode.
+// Operations staged in dry run mode cannot be committed.
+func (o *Organizer) CommitDecisions() (*OrganizerStatus, error) {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	if err := o.commitDecisions(); err != nil {
+		return nil, err
+	}
+
+	return o.organizerStatus()
+}
+
+func (o *Organizer) commitDecisions() error {
+	return o.organizer.commitDecisions()
+}
+
+func (o *organizer) commitDecisions() error {
+	if !o.hasConfig() {
+		return errors.New("no configuration loaded")
+	}
+	if o.config.Ops.DryRun {
+		return errors.New("operations cannot be committed in dry run mode")
+	}
+
+	o.opsMutex.Lock()
+	var staged []*Operation
+	for _, op := range o.operations {
+		if op.Status == OpStatusStaged {
+			op.Status = OpStatusQueued
+			staged = append(staged, op)
+		}
+	}
+	o.opsMutex.Unlock()
+
+	for _, op := range staged {
+		o.enqueueOperation(op)
+	}
 
 	return nil
 }
 
-func (o *organizer) createOperation(hotkey string) (*Operation, error) {
-	dstDir, ok := o.getDstDir(hotkey)
+// createOperations returns the operations sending the current file
+// to the destination directories associated to the given events.
+// All the operations but the last one are copies or links, and the last one
+// starts only after the others have finished.
+// Destination directories selected more than once are rejected
+// before rendering any template.
+func (o *organizer) createOperations(events []*HotkeyEvent) ([]*Operation, error) {
+	dstDirs := make(map[*DstDir]bool)
+	for _, event := range events {
+		dstDir, _, ok := o.resolveHotkey(event)
+		if !ok {
+			return nil, fmt.Errorf("hotkey %q not found", event)
+		}
+		if dstDirs[dstDir] {
+			return nil, fmt.Errorf("destination %q selected more than once", dstDir.Dir)
+		}
+		dstDirs[dstDir] = true
+	}
+
+	ops := make([]*Operation, 0, len(events))
+	dstPaths := make(map[string]bool)
+	for i, event := range events {
+		op, err := o.createOperation(event)
+		if err == nil && dstPaths[op.DstPath] {
+			err = fmt.Errorf("destination %q selected more than once", op.DstPath)
+		}
+		if err == nil && op.Op == OpTypeTrash && i < len(events)-1 {
+			err = errors.New("files can be trashed only after all the other operations")
+		}
+		if err != nil {
+			if op != nil {
+				ops = append(ops, op)
+			}
+			o.releaseCounters(ops)
+			return nil, err
+		}
+		dstPaths[op.DstPath] = true
+		ops = append(ops, op)
+	}
+	orderOperations(ops)
+	return ops, nil
+}
+
+// releaseCounters releases the counter values of the given operations,
+// in reverse order of creation.
+func (o *organizer) releaseCounters(ops []*Operation) {
+	for i := len(ops) - 1; i >= 0; i-- {
+		o.releaseCounter(ops[i])
+	}
+}
+
+// orderOperations turns the moves among all the given operations but the last one
+// into copies and makes the last one wait for the others.
+// Links are left as they are, since they do not move the file.
+func orderOperations(ops []*Operation) {
+	if len(ops) < 2 {
+		return
+	}
+	last := len(ops) - 1
+	for _, op := range ops[:last] {
+		if op.Op == OpTypeMove {
+			op.Op = OpTypeCopy
+		}
+	}
+	ops[last].after = ops[:last]
+}
+
+func (o *organizer) createOperation(event *HotkeyEvent) (*Operation, error) {
+	dstDir, invert, ok := o.resolveHotkey(event)
 	if !ok {
-		return nil, fmt.Errorf("hotkey %q not found", hotkey)
+		return nil, fmt.Errorf("hotkey %q not found", event)
 	}
 
 	file := o.currentFile()
@@ -310,44 +819,1113 @@ func (o *organizer) createOperation(hotkey string) (*Operation, error) {
 		return nil, fmt.Errorf("no current file found")
 	}
 
+	opType := o.srcDirOf(file).DefaultOpType
+	if dstDir.OpType != "" {
+		opType = dstDir.OpType
+	}
+	if invert {
+		opType = opType.Inverted()
+	}
+
+	relPath, templated, err := o.dstRelPath(dstDir, file)
+	if err != nil {
+		return nil, err
+	}
+
+	dstPath := filepath.Join(dstDir.Dir, relPath)
+	if opType == OpTypeTrash {
+		trashPath, err := trashFilesPath(file.Name)
+		if err != nil {
+			return nil, err
+		}
+		dstPath = trashPath
+	}
+	dstPath, err = o.reserveDstPath(dstPath, o.config.Ops.MaxTries)
+	if err != nil {
+		return nil, err
+	}
+
 	op := &Operation{
-		ID:       file.ID,
-		Op:       o.config.Src.DefaultOpType,
-		SrcPath:  file.Path,
-		DstPath:  filepath.Join(dstDir, file.Name),
-		MaxTries: o.config.Ops.MaxTries,
+		ID:              o.nextOperationID(),
+		FileID:          file.ID,
+		Op:              opType,
+		SrcPath:         file.Path,
+		DstPath:         dstPath,
+		MaxTries:        o.config.Ops.MaxTries,
+		CollisionPolicy: o.config.Ops.CollisionPolicy,
+		Status:          OpStatusQueued,
+		done:            make(chan struct{}),
+		dstRelPath:      relPath,
+	}
+	if templated {
+		op.counterHotkey = dstDir.Hotkey
+		op.counter = o.nextCounter(dstDir.Hotkey)
+		o.takeCounter(op)
 	}
 
 	return op, nil
 }
 
-func (o *organizer) executeOperation(op *Operation) {
-	opType := op.Op
-	srcPath := op.SrcPath
-	dstPath := op.DstPath
-	maxTries := op.MaxTries
+// dstRelPath returns the path of the given file relative to the given destination directory,
+// rendering its subpath and rename templates, if any, and whether any template was rendered.
+// Trashed files keep their names.
+func (o *organizer) dstRelPath(dstDir *DstDir, file *File) (string, bool, error) {
+	noTemplates := dstDir.Rename == "" && dstDir.Subpath == ""
+	if noTemplates || dstDir.isTrash() {
+		return file.Name, false, nil
+	}
 
-	switch opType {
-	case OpTypeCopy:
-		_, _ = fs.CopyFileSafe(srcPath, dstPath, maxTries)
-	case OpTypeMove:
-		_, _ = fs.MoveFileSafe(srcPath, dstPath, maxTries)
+	vars := &templateVars{
+		file:    file,
+		srcDir:  o.srcDirOf(file).Dir,
+		counter: o.nextCounter(dstDir.Hotkey),
 	}
+	name := file.Name
+	if dstDir.Rename != "" {
+		var err error
+		if name, err = renderFileName(dstDir.Rename, vars); err != nil {
+			return "", false, err
+		}
+	}
+	var subpath string
+	if dstDir.Subpath != "" {
+		var err error
+		if subpath, err = renderSubpath(dstDir.Subpath, vars); err != nil {
+			return "", false, err
+		}
+	}
+	return filepath.Join(subpath, name), true, nil
 }
 
-func (o *organizer) incrementCurrentFileIndex() {
-	if o.hasCurrentFile() {
-		o.currentFileIndex++
+// nextCounter returns the value of the rename templates' counter
+// for the next file sent to the destination directory with the given hotkey,
+// reusing the lowest value released by a canceled or reverted operation.
+func (o *organizer) nextCounter(hotkey string) int {
+	free := o.freeCounters[hotkey]
+	if len(free) == 0 {
+		return o.counters[hotkey] + 1
+	}
+	next := free[0]
+	for _, counter := range free[1:] {
+		if counter < next {
+			next = counter
+		}
 	}
+	return next
 }
 
-func (o *organizer) getDstDir(hotkey string) (string, bool) {
-	for _, d := range o.config.Dst.Dirs {
-		if d.Hotkey == hotkey {
-			return d.Dir, true
+// takeCounter marks the counter value of the given operation as used.
+func (o *organizer) takeCounter(op *Operation) {
+	if op.counter == 0 || op.counterHeld {
+		return
+	}
+	op.counterHeld = true
+
+	hotkey := op.counterHotkey
+	if o.removeFreeCounter(hotkey, op.counter) {
+		return
+	}
+	for counter := o.counters[hotkey] + 1; counter < op.counter; counter++ {
+		o.freeCounters[hotkey] = append(o.freeCounters[hotkey], counter)
+	}
+	if op.counter > o.counters[hotkey] {
+		o.counters[hotkey] = op.counter
+	}
+}
+
+// releaseCounter makes the counter value of the given operation available again,
+// so that no gaps are left in the names of the files sent afterwards.
+func (o *organizer) releaseCounter(op *Operation) {
+	if op.counter == 0 || !op.counterHeld {
+		return
+	}
+	op.counterHeld = false
+
+	hotkey := op.counterHotkey
+	if op.counter != o.counters[hotkey] {
+		o.freeCounters[hotkey] = append(o.freeCounters[hotkey], op.counter)
+		return
+	}
+
+	// Lower the counter past the values already released.
+	o.counters[hotkey]--
+	for o.counters[hotkey] > 0 && o.removeFreeCounter(hotkey, o.counters[hotkey]) {
+		o.counters[hotkey]--
+	}
+}
+
+// removeFreeCounter removes the given value from the free counters of the given hotkey
+// and reports whether it was found.
+func (o *organizer) removeFreeCounter(hotkey string, counter int) bool {
+	free := o.freeCounters[hotkey]
+	for i, c := range free {
+		if c == counter {
+			o.freeCounters[hotkey] = append(free[:i:i], free[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// reserveDstPath returns the first destination, following the same naming scheme
+// used to resolve collisions, not targeted by another staged or pending operation.
+// Since operations are created one at a time, the returned destination is reserved
+// as soon as its operation is added to the organizer's operations.
+func (o *organizer) reserveDstPath(dstPath string, maxTries int) (string, error) {
+	o.opsMutex.Lock()
+	defer o.opsMutex.Unlock()
+
+	return o.unreservedDstPath(dstPath, maxTries, nil)
+}
+
+// unreservedDstPath returns the first destination not targeted
+// by a staged or pending operation other than the given one.
+// The caller must hold opsMutex.
+func (o *organizer) unreservedDstPath(dstPath string, maxTries int, except *Operation) (string, error) {
+	dir, name := filepath.Split(filepath.Clean(dstPath))
+	for i := 0; i <= maxTries; i++ {
+		candidate := filepath.Join(dir, insertCounter(name, i))
+		if !o.isReserved(candidate, except) {
+			return candidate, nil
+		}
+	}
+	return "", fmt.Errorf("no available destination for %q after %d tries", dstPath, maxTries)
+}
+
+// isReservedByOthers is like isReserved, but acquires opsMutex.
+func (o *organizer) isReservedByOthers(dstPath string, op *Operation) bool {
+	o.opsMutex.Lock()
+	defer o.opsMutex.Unlock()
+
+	return o.isReserved(dstPath, op)
+}
+
+// isReserved returns true if the given destination is targeted
+// by a staged or pending operation other than the given one.
+// The caller must hold opsMutex.
+func (o *organizer) isReserved(dstPath string, except *Operation) bool {
+	for _, op := range o.operations {
+		if op == except || op.DstPath != dstPath {
+			continue
+		}
+		switch op.Status {
+		case OpStatusStaged, OpStatusQueued, OpStatusRunning:
+			return true
 		}
 	}
-	return "", false
+	return false
+}
+
+// nextOperationID returns a new operation ID, never used before by the organizer,
+// including for operations, like restored records, that are not tracked
+// with the organizer's operations.
+func (o *organizer) nextOperationID() int64 {
+	o.lastOperationID++
+	return o.lastOperationID
+}
+
+// runOperation executes the given operation unless it was canceled
+// while waiting in the worker pool queue.
+// Operations ordered after other operations wait for them to finish
+// and fail if any of them did not succeed.
+func (o *organizer) runOperation(op *Operation) {
+	o.opsMutex.Lock()
+	done := op.done
+	o.opsMutex.Unlock()
+	defer close(done)
+
+	waitErr := o.waitOperations(op.after)
+
+	if !o.startOperation(op) {
+		return
+	}
+
+	var finalDstPath string
+	var outcome OpOutcome
+	err := waitErr
+	if err == nil {
+		finalDstPath, outcome, err = o.executeOperation(op)
+	}
+
+	o.opsMutex.Lock()
+	defer o.opsMutex.Unlock()
+	op.FinalDstPath = finalDstPath
+	op.Outcome = outcome
+	if err != nil {
+		op.Status = OpStatusFailed
+		op.Err = err.Error()
+	} else {
+		op.Status = OpStatusSucceeded
+	}
+	_ = o.journal.complete(o.session, op)
+}
+
+// waitOperations waits for the given operations to finish
+// and returns an error if any of them did not succeed.
+func (o *organizer) waitOperations(ops []*Operation) error {
+	for _, op := range ops {
+		o.opsMutex.Lock()
+		done := op.done
+		o.opsMutex.Unlock()
+
+		<-done
+
+		o.opsMutex.Lock()
+		status := op.Status
+		o.opsMutex.Unlock()
+		if status != OpStatusSucceeded {
+			return fmt.Errorf("operation %d did not succeed", op.ID)
+		}
+	}
+	return nil
+}
+
+func (o *organizer) startOperation(op *Operation) bool {
+	o.opsMutex.Lock()
+	defer o.opsMutex.Unlock()
+
+	if op.Status != OpStatusQueued {
+		return false
+	}
+	op.Status = OpStatusRunning
+	return true
+}
+
+// executeOperation executes the given operation, resolving collisions
+// at its destination according to its collision policy.
+// It returns the final destination, empty if the file was left untouched,
+// and the outcome of the operation.
+func (o *organizer) executeOperation(op *Operation) (string, OpOutcome, error) {
+	opType := op.Op
+	srcPath := op.SrcPath
+	dstPath := op.DstPath
+	maxTries := op.MaxTries
+
+	if !opType.IsValid() {
+		return "", "", fmt.Errorf("operation type %q not valid", opType)
+	}
+
+	isReserved := func(path string) bool {
+		return o.isReservedByOthers(path, op)
+	}
+
+	// Trashed files are never overwritten, whatever the collision policy.
+	if opType == OpTypeTrash {
+		finalDstPath, err := trashFile(srcPath, dstPath, maxTries, isReserved)
+		if err != nil {
+			return "", "", err
+		}
+		outcome := OpOutcomeCreated
+		if finalDstPath != filepath.Clean(dstPath) {
+			outcome = OpOutcomeRenamed
+		}
+		return finalDstPath, outcome, nil
+	}
+
+	outcome, err := collisionOutcome(op)
+	if err != nil {
+		return "", "", err
+	}
+	if outcome.isSkipped() {
+		return "", outcome, nil
+	}
+
+	if err := o.makeDstSubdirs(dstPath); err != nil {
+		return "", "", err
+	}
+
+	if outcome == OpOutcomeOverwritten {
+		switch opType {
+		case OpTypeCopy:
+			err = fs.CopyFile(srcPath, dstPath)
+		case OpTypeMove:
+			err = fs.MoveFile(srcPath, dstPath)
+		case OpTypeSymlink, OpTypeHardlink:
+			err = relinkFile(opType, srcPath, dstPath)
+		}
+		if err != nil {
+			return "", "", err
+		}
+		return dstPath, outcome, nil
+	}
+
+	// Destinations reserved by other operations are skipped.
+	dir, name := filepath.Split(filepath.Clean(dstPath))
+	for i := 0; i <= maxTries; i++ {
+		candidate := filepath.Join(dir, insertCounter(name, i))
+		if isReserved(candidate) {
+			continue
+		}
+
+		var finalDstPath string
+		switch opType {
+		case OpTypeCopy:
+			finalDstPath, err = fs.CopyFileSafe(srcPath, candidate, 0)
+		case OpTypeMove:
+			finalDstPath, err = fs.MoveFileSafe(srcPath, candidate, 0)
+		case OpTypeSymlink, OpTypeHardlink:
+			finalDstPath, err = candidate, linkFile(opType, srcPath, candidate)
+		}
+		if err == fs.MaxTriesErr || os.IsExist(err) {
+			continue
+		}
+		if err != nil {
+			return "", "", err
+		}
+		outcome = OpOutcomeCreated
+		if i > 0 {
+			outcome = OpOutcomeRenamed
+		}
+		return finalDstPath, outcome, nil
+	}
+	return "", "", fs.MaxTriesErr
+}
+
+// makeDstSubdirs creates the missing parent directories of dstPath
+// inside the destination directory with a subpath template holding it.
+// The destination directory itself is never created.
+func (o *organizer) makeDstSubdirs(dstPath string) error {
+	parent := filepath.Dir(dstPath)
+	for _, d := range o.config.Dst.Dirs {
+		if d.Subpath == "" || d.isTrash() {
+			continue
+		}
+		rel, err := filepath.Rel(d.Dir, parent)
+		if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			continue
+		}
+		if _, err := os.Stat(d.Dir); err != nil {
+			return err
+		}
+		return os.MkdirAll(parent, 0755)
+	}
+	return nil
+}
+
+// ExecutionPlan returns the plan of the operations staged or pending,
+// predicting the name collisions at their destinations.
+// In dry run mode, the plan contains all the operations decided so far.
+func (o *Organizer) ExecutionPlan() (*Plan, error) {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	return o.executionPlan()
+}
+
+func (o *Organizer) executionPlan() (*Plan, error) {
+	return o.organizer.executionPlan()
+}
+
+func (o *organizer) executionPlan() (*Plan, error) {
+	if !o.hasConfig() {
+		return nil, errors.New("no configuration loaded")
+	}
+
+	o.opsMutex.Lock()
+	defer o.opsMutex.Unlock()
+
+	var ops []*Operation
+	for _, op := range o.operations {
+		if op.Status == OpStatusStaged || op.Status == OpStatusQueued {
+			ops = append(ops, op)
+		}
+	}
+	return newPlan(ops), nil
+}
+
+// ExportPlanScript returns the execution plan as a POSIX shell script.
+func (o *Organizer) ExportPlanScript() (string, error) {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	plan, err := o.executionPlan()
+	if err != nil {
+		return "", err
+	}
+	return plan.Script(), nil
+}
+
+// ExportPlanCSV returns the execution plan in CSV format, with a src,dst,op header.
+func (o *Organizer) ExportPlanCSV() (string, error) {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	plan, err := o.executionPlan()
+	if err != nil {
+		return "", err
+	}
+	return plan.CSV()
+}
+
+// ImportPlanCSV submits to the worker pool the operations listed in the given plan,
+// in the format returned by ExportPlanCSV.
+// Imported operations are tracked like the other operations
+// and can be retried if they fail.
+func (o *Organizer) ImportPlanCSV(plan string) (*OrganizerStatus, error) {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	if err := o.importPlanCSV(plan); err != nil {
+		return nil, err
+	}
+
+	return o.organizerStatus()
+}
+
+func (o *Organizer) importPlanCSV(plan string) error {
+	return o.organizer.importPlanCSV(plan)
+}
+
+func (o *organizer) importPlanCSV(plan string) error {
+	if !o.hasConfig() {
+		return errors.New("no configuration loaded")
+	}
+	if o.config.Ops.DryRun {
+		return errors.New("plans cannot be imported in dry run mode")
+	}
+
+	steps, err := parsePlanCSV(strings.NewReader(plan))
+	if err != nil {
+		return err
+	}
+
+	for _, step := range steps {
+		dstPath, err := o.reserveDstPath(step.DstPath, o.config.Ops.MaxTries)
+		if err != nil {
+			return err
+		}
+
+		o.opsMutex.Lock()
+		op := &Operation{
+			ID:              o.nextOperationID(),
+			Op:              step.Op,
+			SrcPath:         step.SrcPath,
+			DstPath:         dstPath,
+			MaxTries:        o.config.Ops.MaxTries,
+			CollisionPolicy: o.config.Ops.CollisionPolicy,
+			Status:          OpStatusQueued,
+			done:            make(chan struct{}),
+		}
+		o.operations = append(o.operations, op)
+		o.opsMutex.Unlock()
+
+		o.enqueueOperation(op)
+	}
+
+	return nil
+}
+
+// Undo reverts the last decision taken with HandleHotkey, going back to the previous file.
+// If the decision submitted an operation, the operation is canceled if still staged or pending
+// or reverted if already executed: moved files are moved back to their
+// original path, trashed files are restored from the trash and copies and links are removed.
+// Staged operations replaced by the decision are staged again.
+// Decisions whose operations were submitted in a previous session cannot be undone.
+func (o *Organizer) Undo() (*OrganizerStatus, error) {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	if err := o.undo(); err != nil {
+		return nil, err
+	}
+	o.saveSession()
+
+	return o.organizerStatus()
+}
+
+func (o *Organizer) undo() error {
+	return o.organizer.undo()
+}
+
+func (o *organizer) undo() error {
+	noConfig := !o.hasConfig()
+	noDecisions := len(o.decisions) == 0
+	if noConfig || noDecisions {
+		return nil
+	}
+
+	last := len(o.decisions) - 1
+	d := o.decisions[last]
+	for _, op := range d.ops {
+		if op.restored {
+			return errors.New("decisions executed in a previous session cannot be undone")
+		}
+	}
+	for i := len(d.ops) - 1; i >= 0; i-- {
+		if err := o.revertOperation(d.ops[i]); err != nil {
+			return err
+		}
+		o.releaseCounter(d.ops[i])
+	}
+	o.restageOperations(d.replaced)
+
+	o.decisions = o.decisions[:last]
+	o.currentFileIndex = d.fileIndex
+
+	return nil
+}
+
+// revertOperation cancels the given operation if it is still staged or pending,
+// otherwise it waits for the operation to finish and then reverts it.
+// Operations that left the file untouched have nothing to revert, while
+// files overwritten by an operation cannot be restored.
+func (o *organizer) revertOperation(op *Operation) error {
+	if o.cancelOperation(op) {
+		return nil
+	}
+
+	<-op.done
+
+	o.opsMutex.Lock()
+	defer o.opsMutex.Unlock()
+
+	notExecuted := op.Status != OpStatusSucceeded || op.Outcome.isSkipped()
+	if notExecuted {
+		return nil
+	}
+
+	switch op.Op {
+	case OpTypeCopy, OpTypeSymlink, OpTypeHardlink:
+		if err := fs.RemoveFile(op.FinalDstPath); err != nil {
+			return err
+		}
+	case OpTypeMove:
+		if _, err := fs.MoveFileSafe(op.FinalDstPath, op.SrcPath, 0); err != nil {
+			return err
+		}
+	case OpTypeTrash:
+		if err := untrashFile(op.FinalDstPath, op.SrcPath); err != nil {
+			return err
+		}
+	}
+	op.Status = OpStatusReverted
+
+	return nil
+}
+
+func (o *organizer) cancelOperation(op *Operation) bool {
+	o.opsMutex.Lock()
+	defer o.opsMutex.Unlock()
+
+	switch op.Status {
+	case OpStatusStaged:
+		op.Status = OpStatusCanceled
+		return true
+	case OpStatusQueued:
+		op.Status = OpStatusCanceled
+		_ = o.journal.complete(o.session, op)
+		return true
+	default:
+		return false
+	}
+}
+
+// RetryOperation submits again the failed operation with the given ID.
+// If dstDir is not empty, the operation's destination is moved to that directory,
+// keeping the subfolders and the name rendered by the destination's templates;
+// trash operations cannot be moved.
+func (o *Organizer) RetryOperation(id int64, dstDir string) (*OrganizerStatus, error) {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	if err := o.retryOperation(id, dstDir); err != nil {
+		return nil, err
+	}
+
+	return o.organizerStatus()
+}
+
+func (o *Organizer) retryOperation(id int64, dstDir string) error {
+	return o.organizer.retryOperation(id, dstDir)
+}
+
+func (o *organizer) retryOperation(id int64, dstDir string) error {
+	noDstDirChange := dstDir == ""
+	if !noDstDirChange && !isDir(dstDir) {
+		return fmt.Errorf("destination directory %q not valid", dstDir)
+	}
+
+	o.opsMutex.Lock()
+	defer o.opsMutex.Unlock()
+
+	op, err := o.failedOperation(id)
+	if err != nil {
+		return err
+	}
+
+	if !noDstDirChange {
+		if op.Op == OpTypeTrash {
+			return errors.New("trashed files cannot be sent to another directory")
+		}
+		relPath := op.dstRelPath
+		if relPath == "" {
+			relPath = filepath.Base(op.DstPath)
+		}
+		// The subfolders rendered by a subpath template are only created
+		// when executing inside a configured destination directory.
+		if subpath := filepath.Dir(relPath); subpath != "." {
+			if err := os.MkdirAll(filepath.Join(dstDir, subpath), 0755); err != nil {
+				return err
+			}
+		}
+		dstPath, err := o.unreservedDstPath(filepath.Join(dstDir, relPath), op.MaxTries, op)
+		if err != nil {
+			return err
+		}
+		op.DstPath = dstPath
+	}
+	o.requeueOperation(op)
+
+	return nil
+}
+
+// RetryAllFailed submits again all the failed operations.
+func (o *Organizer) RetryAllFailed() (*OrganizerStatus, error) {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	o.retryAllFailed()
+
+	return o.organizerStatus()
+}
+
+func (o *Organizer) retryAllFailed() {
+	o.organizer.retryAllFailed()
+}
+
+func (o *organizer) retryAllFailed() {
+	o.opsMutex.Lock()
+	defer o.opsMutex.Unlock()
+
+	for _, op := range o.operations {
+		if op.Status == OpStatusFailed {
+			o.requeueOperation(op)
+		}
+	}
+}
+
+// requeueOperation resets the given operation and submits it to the worker pool.
+// The caller must hold opsMutex.
+func (o *organizer) requeueOperation(op *Operation) {
+	if dstPath, err := o.unreservedDstPath(op.DstPath, op.MaxTries, op); err == nil {
+		op.DstPath = dstPath
+	}
+	op.Status = OpStatusQueued
+	op.FinalDstPath = ""
+	op.Outcome = ""
+	op.Err = ""
+	op.done = make(chan struct{})
+	o.enqueueOperation(op)
+}
+
+// DismissOperation marks the failed operation with the given ID as dismissed,
+// removing it from the list of failed operations.
+func (o *Organizer) DismissOperation(id int64) (*OrganizerStatus, error) {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	if err := o.dismissOperation(id); err != nil {
+		return nil, err
+	}
+
+	return o.organizerStatus()
+}
+
+func (o *Organizer) dismissOperation(id int64) error {
+	return o.organizer.dismissOperation(id)
+}
+
+func (o *organizer) dismissOperation(id int64) error {
+	o.opsMutex.Lock()
+	defer o.opsMutex.Unlock()
+
+	op, err := o.failedOperation(id)
+	if err != nil {
+		return err
+	}
+	op.Status = OpStatusDismissed
+
+	return nil
+}
+
+// UnfinishedOperations returns the operations submitted in previous sessions
+// that were never completed, for example because the application was closed
+// before executing them or because it crashed.
+func (o *Organizer) UnfinishedOperations() ([]*Operation, error) {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	keys, ops, err := o.journal.unfinished(o.organizer.session)
+	if err != nil {
+		return nil, err
+	}
+
+	unfinished := make([]*Operation, len(keys))
+	for i, key := range keys {
+		unfinished[i] = ops[key]
+	}
+	return unfinished, nil
+}
+
+// ReplayUnfinished submits again, using the current configuration,
+// the operations returned by UnfinishedOperations.
+func (o *Organizer) ReplayUnfinished() (*OrganizerStatus, error) {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	if err := o.replayUnfinished(); err != nil {
+		return nil, err
+	}
+
+	return o.organizerStatus()
+}
+
+func (o *Organizer) replayUnfinished() error {
+	return o.organizer.replayUnfinished()
+}
+
+func (o *organizer) replayUnfinished() error {
+	if !o.hasConfig() {
+		return errors.New("no configuration loaded")
+	}
+
+	keys, ops, err := o.journal.unfinished(o.session)
+	if err != nil {
+		return err
+	}
+
+	for _, key := range keys {
+		unfinishedOp := ops[key]
+		dstPath, err := o.reserveDstPath(unfinishedOp.DstPath, unfinishedOp.MaxTries)
+		if err != nil {
+			return err
+		}
+
+		o.opsMutex.Lock()
+		op := &Operation{
+			ID:              o.nextOperationID(),
+			Op:              unfinishedOp.Op,
+			SrcPath:         unfinishedOp.SrcPath,
+			DstPath:         dstPath,
+			MaxTries:        unfinishedOp.MaxTries,
+			CollisionPolicy: unfinishedOp.CollisionPolicy,
+			Status:          OpStatusQueued,
+			done:            make(chan struct{}),
+		}
+		o.operations = append(o.operations, op)
+		o.opsMutex.Unlock()
+
+		o.enqueueOperation(op)
+		_ = o.journal.discard(key)
+	}
+
+	return nil
+}
+
+// DiscardUnfinished forgets the operations returned by UnfinishedOperations.
+func (o *Organizer) DiscardUnfinished() (*OrganizerStatus, error) {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	keys, _, err := o.journal.unfinished(o.organizer.session)
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range keys {
+		if err := o.journal.discard(key); err != nil {
+			return nil, err
+		}
+	}
+
+	return o.organizerStatus()
+}
+
+// failedOperation returns the failed operation with the given ID.
+// The caller must hold opsMutex.
+func (o *organizer) failedOperation(id int64) (*Operation, error) {
+	for _, op := range o.operations {
+		if op.ID != id {
+			continue
+		}
+		if op.Status != OpStatusFailed {
+			return nil, fmt.Errorf("operation %d has not failed", id)
+		}
+		return op, nil
+	}
+	return nil, fmt.Errorf("operation %d not found", id)
+}
+
+// PreviousFile goes back to the previous file, if any.
+func (o *Organizer) PreviousFile() (*OrganizerStatus, error) {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	o.previousFile()
+	o.saveSession()
+
+	return o.organizerStatus()
+}
+
+func (o *Organizer) previousFile() {
+	o.organizer.previousFile()
+}
+
+func (o *organizer) previousFile() {
+	if o.hasConfig() && o.currentFileIndex > 0 {
+		o.currentFileIndex--
+	}
+}
+
+// GoToFile makes the file at the given zero-based index the current file.
+func (o *Organizer) GoToFile(index int) (*OrganizerStatus, error) {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	if err := o.goToFile(index); err != nil {
+		return nil, err
+	}
+	o.saveSession()
+
+	return o.organizerStatus()
+}
+
+func (o *Organizer) goToFile(index int) error {
+	return o.organizer.goToFile(index)
+}
+
+func (o *organizer) goToFile(index int) error {
+	if !o.hasConfig() {
+		return errors.New("no configuration loaded")
+	}
+
+	outOfRange := index < 0 || index >= len(o.files)
+	if outOfRange {
+		return fmt.Errorf("file index %d out of range", index)
+	}
+
+	o.currentFileIndex = index
+	return nil
+}
+
+// GoToFileByName makes the next file whose name matches the given
+// shell pattern, as defined by filepath.Match, the current file.
+// The search starts after the current file and wraps around.
+func (o *Organizer) GoToFileByName(pattern string) (*OrganizerStatus, error) {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	if err := o.goToFileByName(pattern); err != nil {
+		return nil, err
+	}
+	o.saveSession()
+
+	return o.organizerStatus()
+}
+
+func (o *Organizer) goToFileByName(pattern string) error {
+	return o.organizer.goToFileByName(pattern)
+}
+
+func (o *organizer) goToFileByName(pattern string) error {
+	if !o.hasConfig() {
+		return errors.New("no configuration loaded")
+	}
+
+	if _, err := filepath.Match(pattern, ""); err != nil {
+		return err
+	}
+
+	numFiles := len(o.files)
+	for i := 1; i <= numFiles; i++ {
+		index := (o.currentFileIndex + i) % numFiles
+		if ok, _ := filepath.Match(pattern, o.files[index].Name); ok {
+			o.currentFileIndex = index
+			return nil
+		}
+	}
+
+	return fmt.Errorf("no file matching %q found", pattern)
+}
+
+// RenameCurrentFile renames the current file, in its directory, to the given name.
+// The new name must be a valid file name not used by another file.
+// Files with operations already submitted cannot be renamed.
+func (o *Organizer) RenameCurrentFile(newName string) (*OrganizerStatus, error) {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	if err := o.renameCurrentFile(newName); err != nil {
+		return nil, err
+	}
+	o.saveSession()
+
+	return o.organizerStatus()
+}
+
+func (o *Organizer) renameCurrentFile(newName string) error {
+	return o.organizer.renameCurrentFile(newName)
+}
+
+func (o *organizer) renameCurrentFile(newName string) error {
+	if !o.hasConfig() {
+		return errors.New("no configuration loaded")
+	}
+	if !o.hasCurrentFile() {
+		return errors.New("no current file")
+	}
+	if !isValidFileName(newName) {
+		return fmt.Errorf("invalid file name %q", newName)
+	}
+	if len(o.currentFileOperations()) > 0 {
+		return errors.New("current file already has operations")
+	}
+
+	file := o.currentFile()
+	if newName == file.Name {
+		return nil
+	}
+	newPath := filepath.Join(file.Dir, newName)
+
+	// A file differing only in case is the current file itself
+	// on case-insensitive filesystems.
+	if newInfo, err := os.Lstat(newPath); err == nil {
+		info, err := os.Lstat(file.Path)
+		if err != nil {
+			return err
+		}
+		if !os.SameFile(info, newInfo) {
+			return fmt.Errorf("file %q already exists", newName)
+		}
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+
+	if err := os.Rename(file.Path, newPath); err != nil {
+		return err
+	}
+	file.Name = newName
+	file.Path = newPath
+	file.Ext = filepath.Ext(newName)
+	file.URL = fileURL(file.SrcID, o.srcDirOf(file).Dir, newPath)
+	return nil
+}
+
+// isValidFileName returns true if the given name can be used
+// as the name of a file in a directory.
+func isValidFileName(name string) bool {
+	invalid := name == "" || name == "." || name == ".." ||
+		strings.ContainsAny(name, "/\x00") || strings.ContainsRune(name, filepath.Separator) ||
+		!utf8.ValidString(name)
+	return !invalid
+}
+
+// fileOperations returns the operations submitted for the file at the given index
+// that have not been canceled or reverted.
+func (o *organizer) fileOperations(fileIndex int) []*Operation {
+	o.opsMutex.Lock()
+	defer o.opsMutex.Unlock()
+
+	ops := []*Operation{}
+	for _, d := range o.decisions {
+		if d.fileIndex != fileIndex {
+			continue
+		}
+		for _, op := range d.ops {
+			if op.Status == OpStatusCanceled || op.Status == OpStatusReverted {
+				continue
+			}
+			fileOp := *op
+			ops = append(ops, &fileOp)
+		}
+	}
+	return ops
+}
+
+// submittedFileIndexes returns the set of indexes of the files
+// with operations submitted, like hasSubmittedOperations.
+func (o *organizer) submittedFileIndexes() map[int]bool {
+	o.opsMutex.Lock()
+	defer o.opsMutex.Unlock()
+
+	indexes := make(map[int]bool)
+	for _, d := range o.decisions {
+		for _, op := range d.ops {
+			switch op.Status {
+			case OpStatusStaged, OpStatusCanceled, OpStatusReverted:
+				continue
+			}
+			indexes[d.fileIndex] = true
+		}
+	}
+	return indexes
+}
+
+func (o *organizer) currentFileOperations() []*Operation {
+	return o.fileOperations(o.currentFileIndex)
+}
+
+func (o *organizer) incrementCurrentFileIndex() {
+	if o.hasCurrentFile() {
+		o.currentFileIndex++
+	}
+}
+
+func (o *organizer) getDstDir(hotkey string) (*DstDir, bool) {
+	for _, d := range o.config.Dst.Dirs {
+		if d.Hotkey == hotkey {
+			return d, true
+		}
+	}
+	return nil, false
+}
+
+// resolveHotkey returns the destination directory associated to the given event
+// and whether its operation type must be inverted.
+// A destination directory configured with the modified hotkey takes precedence,
+// followed by one configured with the typed character, like "A" or "!";
+// otherwise, the shift modifier inverts the operation type
+// of the destination directory associated to the unmodified key.
+func (o *organizer) resolveHotkey(event *HotkeyEvent) (*DstDir, bool, bool) {
+	if dstDir, ok := o.getDstDir(event.String()); ok {
+		return dstDir, false, true
+	}
+	if event.Raw != "" {
+		if dstDir, ok := o.getDstDir(event.Raw); ok {
+			return dstDir, false, true
+		}
+	}
+	if !event.Shift {
+		return nil, false, false
+	}
+	dstDir, ok := o.getDstDir(event.Key)
+	return dstDir, ok, ok
+}
+
+// observeCurrentFile records the size and modification time of the current file,
+// if the configuration requires stable files; see checkCurrentFileReady.
+func (o *organizer) observeCurrentFile() {
+	if o.hasCurrentFile() && o.config.Src.CheckStable {
+		_ = observeFile(o.currentFile())
+	}
+}
+
+// checkCurrentFileReady returns an error if the current file is gone
+// or if it is still being written and the configuration requires stable files.
+// The current file is not modified, as its size and modification time
+// are only recorded when it is first seen, when the source directories change
+// and when deciding on it.
+func (o *organizer) checkCurrentFileReady() error {
+	if !o.hasCurrentFile() {
+		return nil
+	}
+	file := o.currentFile()
+	if file.Gone {
+		return fmt.Errorf("file %q is gone from the source directory", file.Name)
+	}
+	if !o.config.Src.CheckStable {
+		return nil
+	}
+	return checkFileStable(file, o.config.Src.StableSeconds)
 }
 
 func (o *organizer) currentFile() *File {
@@ -1,15 +1,43 @@
 package core
 
 import (
+	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 )
 
+// TestMain runs the tests with a temporary user configuration directory,
+// so that the journal, the session and the latest configuration written
+// by organizers created with NewOrganizer do not touch the user's files.
+func TestMain(m *testing.M) {
+	os.Exit(runWithTempConfigDir(m))
+}
+
+func runWithTempConfigDir(m *testing.M) int {
+	dir, err := ioutil.TempDir("", "config")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	defer os.RemoveAll(dir)
+
+	// os.UserConfigDir reads XDG_CONFIG_HOME on Unix systems, HOME on macOS
+	// and AppData on Windows.
+	for _, env := range []string{"XDG_CONFIG_HOME", "HOME", "AppData"} {
+		if err := os.Setenv(env, dir); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+	}
+	return m.Run()
+}
+
 func TestNewOrganizer(t *testing.T) {
 	assert := assert.New(t)
 
@@ -17,6 +45,17 @@ func TestNewOrganizer(t *testing.T) {
 	assert.NotNil(got, "TestNewOrganizer")
 }
 
+func TestDefaultPathsInTempConfigDir(t *testing.T) {
+	assert := assert.New(t)
+	name := "TestDefaultPathsInTempConfigDir"
+
+	teclaDir, err := teclaConfigDir()
+	assert.Nil(err, name)
+	assert.True(strings.HasPrefix(teclaDir, os.TempDir()), name)
+	assert.Equal(filepath.Join(teclaDir, defaultSessionFilename), defaultSessionPath(), name)
+	assert.Equal(filepath.Join(teclaDir, defaultJournalFilename), DefaultJournal().path, name)
+}
+
 func TestOrganizer_LoadConfig(t *testing.T) {
 	assert := assert.New(t)
 
@@ -71,10 +110,12 @@ func TestOrganizer_LoadConfig(t *testing.T) {
 					Path: filepath.Join(testdataDir, "10.gif"),
 					Ext:  ".gif",
 					Size: 799,
-					URL:  defaultFileServerAddr + "/10.gif",
+					URL:  defaultFileServerAddr + "/0/10.gif",
 				},
-				CurrentFileIndex: 0,
-				NumFiles:         2,
+				CurrentFileIndex:      0,
+				NumFiles:              2,
+				Operations:            &OperationsStatus{Failed: []*Operation{}},
+				CurrentFileOperations: []*Operation{},
 			},
 			false,
 		},
@@ -93,10 +134,12 @@ func TestOrganizer_LoadConfig(t *testing.T) {
 					Path: filepath.Join(testdataDir, "10.gif"),
 					Ext:  ".gif",
 					Size: 799,
-					URL:  defaultFileServerAddr + "/10.gif",
+					URL:  defaultFileServerAddr + "/0/10.gif",
 				},
-				CurrentFileIndex: 0,
-				NumFiles:         8,
+				CurrentFileIndex:      0,
+				NumFiles:              8,
+				Operations:            &OperationsStatus{Failed: []*Operation{}},
+				CurrentFileOperations: []*Operation{},
 			},
 			false,
 		},
@@ -239,10 +282,12 @@ func TestOrganizer_HandleHotkey(t *testing.T) {
 					Path: filepath.Join(testdataDir, "10.gif"),
 					Ext:  ".gif",
 					Size: 799,
-					URL:  defaultFileServerAddr + "/10.gif",
+					URL:  defaultFileServerAddr + "/0/10.gif",
 				},
-				CurrentFileIndex: 0,
-				NumFiles:         2,
+				CurrentFileIndex:      0,
+				NumFiles:              2,
+				Operations:            &OperationsStatus{Failed: []*Operation{}},
+				CurrentFileOperations: []*Operation{},
 			},
 			false,
 		},
@@ -261,10 +306,12 @@ func TestOrganizer_HandleHotkey(t *testing.T) {
 					Path: filepath.Join(testdataDir, "20.gif"),
 					Ext:  ".gif",
 					Size: 799,
-					URL:  defaultFileServerAddr + "/20.gif",
+					URL:  defaultFileServerAddr + "/0/20.gif",
 				},
-				CurrentFileIndex: 1,
-				NumFiles:         2,
+				CurrentFileIndex:      1,
+				NumFiles:              2,
+				Operations:            &OperationsStatus{NumQueued: 1, Failed: []*Operation{}},
+				CurrentFileOperations: []*Operation{},
 			},
 			false,
 		},
@@ -283,10 +330,12 @@ func TestOrganizer_HandleHotkey(t *testing.T) {
 					Path: filepath.Join(testdataDir, "20.gif"),
 					Ext:  ".gif",
 					Size: 799,
-					URL:  defaultFileServerAddr + "/20.gif",
+					URL:  defaultFileServerAddr + "/0/20.gif",
 				},
-				CurrentFileIndex: 1,
-				NumFiles:         2,
+				CurrentFileIndex:      1,
+				NumFiles:              2,
+				Operations:            &OperationsStatus{Failed: []*Operation{}},
+				CurrentFileOperations: []*Operation{},
 			},
 			false,
 		},
@@ -324,10 +373,12 @@ func TestOrganizerInteractionCopy(t *testing.T) {
 			Path: filepath.Join(testdataDir, "10.gif"),
 			Ext:  ".gif",
 			Size: 799,
-			URL:  defaultFileServerAddr + "/10.gif",
+			URL:  defaultFileServerAddr + "/0/10.gif",
 		},
-		CurrentFileIndex: 0,
-		NumFiles:         2,
+		CurrentFileIndex:      0,
+		NumFiles:              2,
+		Operations:            &OperationsStatus{Failed: []*Operation{}},
+		CurrentFileOperations: []*Operation{},
 	}
 	assert.Equal(wantStatus, status, name)
 	assert.Nil(err, name)
@@ -346,7 +397,7 @@ func TestOrganizerInteractionCopy(t *testing.T) {
 		Path: filepath.Join(testdataDir, "20.gif"),
 		Ext:  ".gif",
 		Size: 799,
-		URL:  defaultFileServerAddr + "/20.gif",
+		URL:  defaultFileServerAddr + "/0/20.gif",
 	}
 	wantStatus.CurrentFileIndex = 1
 	assert.Equal(wantStatus, status, name)
@@ -361,10 +412,13 @@ func TestOrganizerInteractionCopy(t *testing.T) {
 	status, err = o.HandleHotkey("x")
 	wantStatus.CurrentFile = nil
 	wantStatus.CurrentFileIndex = 2
+	wantStatus.Operations.NumQueued = 1
 	assert.Equal(wantStatus, status, name)
 	assert.Nil(err, name)
 	time.Sleep(300 * time.Millisecond)
 	assert.FileExists(filepath.Join(dir1, "20.gif"))
+	wantStatus.Operations.NumQueued = 0
+	wantStatus.Operations.NumSucceeded = 1
 
 	// No more files, ignore valid hotkey
 	status, err = o.HandleHotkey("x")
@@ -383,7 +437,7 @@ func TestOrganizerInteractionCopy(t *testing.T) {
 
 	// Drop config and wait for operations to finish
 	status, err = o.DropConfigWait()
-	wantStatus = &OrganizerStatus{}
+	wantStatus = &OrganizerStatus{Operations: wantStatus.Operations}
 	assert.Equal(wantStatus, status, name)
 	assert.Nil(err, name)
 }
@@ -422,10 +476,12 @@ func TestOrganizerInteractionMove(t *testing.T) {
 			Path: filepath.Join(dir1, "file1.txt"),
 			Ext:  ".txt",
 			Size: 3,
-			URL:  defaultFileServerAddr + "/file1.txt",
+			URL:  defaultFileServerAddr + "/0/file1.txt",
 		},
-		CurrentFileIndex: 0,
-		NumFiles:         2,
+		CurrentFileIndex:      0,
+		NumFiles:              2,
+		Operations:            &OperationsStatus{Failed: []*Operation{}},
+		CurrentFileOperations: []*Operation{},
 	}
 	assert.Equal(wantStatus, status, name)
 	assert.Nil(err, name)
@@ -444,7 +500,7 @@ func TestOrganizerInteractionMove(t *testing.T) {
 		Path: filepath.Join(dir1, "file2.txt"),
 		Ext:  ".txt",
 		Size: 3,
-		URL:  defaultFileServerAddr + "/file2.txt",
+		URL:  defaultFileServerAddr + "/0/file2.txt",
 	}
 	wantStatus.CurrentFileIndex = 1
 	assert.Equal(wantStatus, status, name)
@@ -459,10 +515,13 @@ func TestOrganizerInteractionMove(t *testing.T) {
 	status, err = o.HandleHotkey("x")
 	wantStatus.CurrentFile = nil
 	wantStatus.CurrentFileIndex = 2
+	wantStatus.Operations.NumQueued = 1
 	assert.Equal(wantStatus, status, name)
 	assert.Nil(err, name)
 	time.Sleep(300 * time.Millisecond)
 	assert.FileExists(filepath.Join(dir2, "file2.txt"))
+	wantStatus.Operations.NumQueued = 0
+	wantStatus.Operations.NumSucceeded = 1
 
 	// No more files, ignore valid hotkey
 	status, err = o.HandleHotkey("x")
@@ -486,10 +545,1925 @@ func TestOrganizerInteractionMove(t *testing.T) {
 	assert.Nil(err, name)
 }
 
-func organizerWithConfig(config *Config) *Organizer {
+func TestOrganizerInteractionUndo(t *testing.T) {
+	assert := assert.New(t)
+	name := "TestOrganizerInteractionUndo"
+
+	dir1, err := ioutil.TempDir("", "dir")
+	assert.Nil(err)
+	defer os.RemoveAll(dir1)
+	file1Path := filepath.Join(dir1, "file1.txt")
+	err = ioutil.WriteFile(file1Path, []byte("123"), 0644)
+	assert.Nil(err)
+	file2Path := filepath.Join(dir1, "file2.txt")
+	err = ioutil.WriteFile(file2Path, []byte("123"), 0644)
+	assert.Nil(err)
+
+	dir2, err := ioutil.TempDir("", "dir")
+	assert.Nil(err)
+	defer os.RemoveAll(dir2)
+
 	o := NewOrganizer()
-	_, _ = o.LoadConfig(config)
-	return o
+	config := configWithSrcDirAndDstDirMove(dir1, dir2)
+
+	// Nothing to undo
+	status, err := o.Undo()
+	assert.Equal(&OrganizerStatus{}, status, name)
+	assert.Nil(err, name)
+
+	_, err = o.LoadConfig(config)
+	assert.Nil(err, name)
+
+	// Undo skip
+	status, err = o.HandleHotkey(" ")
+	assert.Nil(err, name)
+	assert.Equal(1, status.CurrentFileIndex, name)
+	status, err = o.Undo()
+	assert.Nil(err, name)
+	assert.Equal(0, status.CurrentFileIndex, name)
+
+	// Undo pending move
+	status, err = o.HandleHotkey("x")
+	assert.Nil(err, name)
+	assert.Equal(1, status.CurrentFileIndex, name)
+	status, err = o.Undo()
+	assert.Nil(err, name)
+	assert.Equal(0, status.CurrentFileIndex, name)
+	time.Sleep(300 * time.Millisecond)
+	assert.FileExists(file1Path, name)
+	_, err = os.Stat(filepath.Join(dir2, "file1.txt"))
+	assert.True(os.IsNotExist(err), name)
+
+	// Undo executed move
+	_, err = o.HandleHotkey("x")
+	assert.Nil(err, name)
+	time.Sleep(300 * time.Millisecond)
+	assert.FileExists(filepath.Join(dir2, "file1.txt"), name)
+	status, err = o.Undo()
+	assert.Nil(err, name)
+	assert.Equal(0, status.CurrentFileIndex, name)
+	assert.Equal("file1.txt", status.CurrentFile.Name, name)
+	assert.FileExists(file1Path, name)
+	_, err = os.Stat(filepath.Join(dir2, "file1.txt"))
+	assert.True(os.IsNotExist(err), name)
+
+	// Undo executed copy
+	config.Src.DefaultOpType = OpTypeCopy
+	_, err = o.LoadConfig(config)
+	assert.Nil(err, name)
+	_, err = o.HandleHotkey("x")
+	assert.Nil(err, name)
+	time.Sleep(300 * time.Millisecond)
+	assert.FileExists(filepath.Join(dir2, "file1.txt"), name)
+	status, err = o.Undo()
+	assert.Nil(err, name)
+	assert.Equal(0, status.CurrentFileIndex, name)
+	assert.FileExists(file1Path, name)
+	_, err = os.Stat(filepath.Join(dir2, "file1.txt"))
+	assert.True(os.IsNotExist(err), name)
+
+	_, err = o.DropConfigWait()
+	assert.Nil(err, name)
+}
+
+func TestOrganizerInteractionFailure(t *testing.T) {
+	assert := assert.New(t)
+	name := "TestOrganizerInteractionFailure"
+
+	dir1, err := ioutil.TempDir("", "dir")
+	assert.Nil(err)
+	defer os.RemoveAll(dir1)
+	file1Path := filepath.Join(dir1, "file1.txt")
+	err = ioutil.WriteFile(file1Path, []byte("123"), 0644)
+	assert.Nil(err)
+
+	dir2, err := ioutil.TempDir("", "dir")
+	assert.Nil(err)
+	defer os.RemoveAll(dir2)
+
+	o := NewOrganizer()
+	config := configWithSrcDirAndDstDirMove(dir1, dir2)
+
+	_, err = o.LoadConfig(config)
+	assert.Nil(err, name)
+
+	// Make the operation fail by removing the destination directory
+	err = os.RemoveAll(dir2)
+	assert.Nil(err, name)
+
+	status, err := o.HandleHotkey("x")
+	assert.Nil(err, name)
+	assert.Equal(1, status.Operations.NumQueued, name)
+	time.Sleep(300 * time.Millisecond)
+
+	status, err = o.OrganizerStatus()
+	assert.Nil(err, name)
+	assert.Equal(0, status.Operations.NumQueued, name)
+	assert.Equal(0, status.Operations.NumSucceeded, name)
+	assert.Equal(1, status.Operations.NumFailed, name)
+	assert.Len(status.Operations.Failed, 1, name)
+	failedOp := status.Operations.Failed[0]
+	assert.Equal(OpStatusFailed, failedOp.Status, name)
+	assert.Equal(file1Path, failedOp.SrcPath, name)
+	assert.Equal("", failedOp.FinalDstPath, name)
+	assert.NotEmpty(failedOp.Err, name)
+	assert.FileExists(file1Path, name)
+
+	// Retry unknown and not failed operations
+	_, err = o.RetryOperation(100, "")
+	assert.NotNil(err, name)
+	_, err = o.DismissOperation(100)
+	assert.NotNil(err, name)
+
+	// Retry all failed operations, destination still missing
+	status, err = o.RetryAllFailed()
+	assert.Nil(err, name)
+	assert.Equal(1, status.Operations.NumQueued, name)
+	assert.Equal(0, status.Operations.NumFailed, name)
+	time.Sleep(300 * time.Millisecond)
+
+	// Retry with a different destination directory
+	dir3, err := ioutil.TempDir("", "dir")
+	assert.Nil(err)
+	defer os.RemoveAll(dir3)
+	_, err = o.RetryOperation(failedOp.ID, filepath.Join(dir3, "missing"))
+	assert.NotNil(err, name)
+	status, err = o.RetryOperation(failedOp.ID, dir3)
+	assert.Nil(err, name)
+	assert.Equal(1, status.Operations.NumQueued, name)
+	time.Sleep(300 * time.Millisecond)
+	status, err = o.OrganizerStatus()
+	assert.Nil(err, name)
+	assert.Equal(1, status.Operations.NumSucceeded, name)
+	assert.Equal(0, status.Operations.NumFailed, name)
+	assert.FileExists(filepath.Join(dir3, "file1.txt"), name)
+	_, err = o.RetryOperation(failedOp.ID, "")
+	assert.NotNil(err, name)
+
+	// Operations failed while dropping the configuration are reported
+	err = ioutil.WriteFile(filepath.Join(dir1, "file2.txt"), []byte("123"), 0644)
+	assert.Nil(err)
+	_, err = o.LoadConfig(configWithSrcDirAndDstDirMove(dir1, filepath.Join(dir3, "missing")))
+	assert.Nil(err, name)
+	_, err = o.HandleHotkey("x")
+	assert.Nil(err, name)
+	status, err = o.DropConfigWait()
+	assert.Nil(err, name)
+	assert.Nil(status.Config, name)
+	assert.Equal(1, status.Operations.NumFailed, name)
+	assert.Len(status.Operations.Failed, 1, name)
+}
+
+func TestOrganizerInteractionRetryElsewhere(t *testing.T) {
+	assert := assert.New(t)
+	name := "TestOrganizerInteractionRetryElsewhere"
+
+	dir1, err := ioutil.TempDir("", "dir")
+	assert.Nil(err)
+	defer os.RemoveAll(dir1)
+	err = ioutil.WriteFile(filepath.Join(dir1, "a.txt"), []byte("123"), 0644)
+	assert.Nil(err)
+
+	dir2, err := ioutil.TempDir("", "dir")
+	assert.Nil(err)
+	defer os.RemoveAll(dir2)
+
+	dir3, err := ioutil.TempDir("", "dir")
+	assert.Nil(err)
+	defer os.RemoveAll(dir3)
+
+	config := configWithSrcDirAndDstDirMove(dir1, dir2)
+	config.Dst.Dirs[0].Rename = "{name}_{counter:1}.{ext}"
+	config.Dst.Dirs[0].Subpath = "sub"
+
+	o := NewOrganizer()
+	_, err = o.LoadConfig(config)
+	assert.Nil(err, name)
+
+	// Make the operation fail by removing the destination directory
+	err = os.RemoveAll(dir2)
+	assert.Nil(err, name)
+	_, err = o.HandleHotkey("x")
+	assert.Nil(err, name)
+	time.Sleep(300 * time.Millisecond)
+	status, err := o.OrganizerStatus()
+	assert.Nil(err, name)
+	assert.Len(status.Operations.Failed, 1, name)
+
+	// The rendered subpath and name are kept
+	_, err = o.RetryOperation(status.Operations.Failed[0].ID, dir3)
+	assert.Nil(err, name)
+
+	// Trash operations cannot be sent elsewhere
+	o.organizer.opsMutex.Lock()
+	trashOp := &Operation{
+		ID:      o.organizer.nextOperationID(),
+		Op:      OpTypeTrash,
+		SrcPath: filepath.Join(dir1, "b.txt"),
+		DstPath: filepath.Join(dir1, "trash", "b.txt"),
+		Status:  OpStatusFailed,
+		done:    make(chan struct{}),
+	}
+	o.organizer.operations = append(o.organizer.operations, trashOp)
+	o.organizer.opsMutex.Unlock()
+	_, err = o.RetryOperation(trashOp.ID, dir3)
+	assert.NotNil(err, name)
+	assert.Equal(filepath.Join(dir1, "trash", "b.txt"), trashOp.DstPath, name)
+	_, err = o.DismissOperation(trashOp.ID)
+	assert.Nil(err, name)
+
+	_, err = o.DropConfigWait()
+	assert.Nil(err, name)
+	assert.FileExists(filepath.Join(dir3, "sub", "a_1.txt"), name)
+}
+
+func TestOrganizerInteractionDismiss(t *testing.T) {
+	assert := assert.New(t)
+	name := "TestOrganizerInteractionDismiss"
+
+	dir1, err := ioutil.TempDir("", "dir")
+	assert.Nil(err)
+	defer os.RemoveAll(dir1)
+	err = ioutil.WriteFile(filepath.Join(dir1, "file1.txt"), []byte("123"), 0644)
+	assert.Nil(err)
+
+	dir2, err := ioutil.TempDir("", "dir")
+	assert.Nil(err)
+
+	o := NewOrganizer()
+	_, err = o.LoadConfig(configWithSrcDirAndDstDirMove(dir1, dir2))
+	assert.Nil(err, name)
+	err = os.RemoveAll(dir2)
+	assert.Nil(err, name)
+
+	_, err = o.HandleHotkey("x")
+	assert.Nil(err, name)
+	time.Sleep(300 * time.Millisecond)
+
+	status, err := o.OrganizerStatus()
+	assert.Nil(err, name)
+	assert.Equal(1, status.Operations.NumFailed, name)
+
+	status, err = o.DismissOperation(status.Operations.Failed[0].ID)
+	assert.Nil(err, name)
+	assert.Equal(0, status.Operations.NumFailed, name)
+	assert.Empty(status.Operations.Failed, name)
+
+	_, err = o.DropConfigWait()
+	assert.Nil(err, name)
+}
+
+func TestOrganizerInteractionJournal(t *testing.T) {
+	assert := assert.New(t)
+	name := "TestOrganizerInteractionJournal"
+
+	dir1, err := ioutil.TempDir("", "dir")
+	assert.Nil(err)
+	defer os.RemoveAll(dir1)
+	file1Path := filepath.Join(dir1, "file1.txt")
+	err = ioutil.WriteFile(file1Path, []byte("123"), 0644)
+	assert.Nil(err)
+	err = ioutil.WriteFile(filepath.Join(dir1, "file2.txt"), []byte("123"), 0644)
+	assert.Nil(err)
+
+	dir2, err := ioutil.TempDir("", "dir")
+	assert.Nil(err)
+	defer os.RemoveAll(dir2)
+
+	dir3, err := ioutil.TempDir("", "journal")
+	assert.Nil(err)
+	defer os.RemoveAll(dir3)
+
+	journal := NewJournal(filepath.Join(dir3, defaultJournalFilename))
+	config := configWithSrcDirAndDstDirMove(dir1, dir2)
+
+	// Operation left unfinished by a crashed session
+	err = journal.submit(1, &Operation{
+		ID:       1,
+		Op:       OpTypeMove,
+		SrcPath:  file1Path,
+		DstPath:  filepath.Join(dir2, "file1.txt"),
+		MaxTries: 1,
+	})
+	assert.Nil(err, name)
+
+	o := NewOrganizerWithJournal(journal)
+	unfinished, err := o.UnfinishedOperations()
+	assert.Nil(err, name)
+	assert.Len(unfinished, 1, name)
+	assert.Equal(file1Path, unfinished[0].SrcPath, name)
+
+	// Replay requires a configuration
+	_, err = o.ReplayUnfinished()
+	assert.NotNil(err, name)
+
+	_, err = o.LoadConfig(config)
+	assert.Nil(err, name)
+	unfinished, err = o.UnfinishedOperations()
+	assert.Nil(err, name)
+	assert.Len(unfinished, 1, name)
+
+	status, err := o.ReplayUnfinished()
+	assert.Nil(err, name)
+	assert.Equal(1, status.Operations.NumQueued, name)
+	unfinished, err = o.UnfinishedOperations()
+	assert.Nil(err, name)
+	assert.Empty(unfinished, name)
+
+	_, err = o.DropConfigWait()
+	assert.Nil(err, name)
+	assert.FileExists(filepath.Join(dir2, "file1.txt"), name)
+
+	// Completed operations are not unfinished
+	_, err = o.LoadConfig(config)
+	assert.Nil(err, name)
+	_, err = o.HandleHotkey("x")
+	assert.Nil(err, name)
+	_, err = o.DropConfigWait()
+	assert.Nil(err, name)
+	unfinished, err = o.UnfinishedOperations()
+	assert.Nil(err, name)
+	assert.Empty(unfinished, name)
+
+	// Discard unfinished operations
+	err = journal.submit(2, &Operation{ID: 1, Op: OpTypeMove})
+	assert.Nil(err, name)
+	unfinished, err = o.UnfinishedOperations()
+	assert.Nil(err, name)
+	assert.Len(unfinished, 1, name)
+	_, err = o.DiscardUnfinished()
+	assert.Nil(err, name)
+	unfinished, err = o.UnfinishedOperations()
+	assert.Nil(err, name)
+	assert.Empty(unfinished, name)
+}
+
+func TestOrganizerInteractionResumeSession(t *testing.T) {
+	assert := assert.New(t)
+	name := "TestOrganizerInteractionResumeSession"
+
+	dir1, err := ioutil.TempDir("", "dir")
+	assert.Nil(err)
+	defer os.RemoveAll(dir1)
+	for _, n := range []string{"a.txt", "b.txt", "c.txt", "d.txt"} {
+		err = ioutil.WriteFile(filepath.Join(dir1, n), []byte("123"), 0644)
+		assert.Nil(err)
+	}
+
+	dir2, err := ioutil.TempDir("", "dir")
+	assert.Nil(err)
+	defer os.RemoveAll(dir2)
+
+	dir3, err := ioutil.TempDir("", "session")
+	assert.Nil(err)
+	defer os.RemoveAll(dir3)
+
+	journal := NewJournal(filepath.Join(dir3, defaultJournalFilename))
+	sessionPath := filepath.Join(dir3, defaultSessionFilename)
+	config := configWithSrcDirAndDstDirMove(dir1, dir2)
+	config.Name = "session"
+
+	// No session to resume
+	o := NewOrganizerWithJournal(journal)
+	o.sessionPath = sessionPath
+	_, err = o.ResumeSession()
+	assert.NotNil(err, name)
+
+	// Skip a, move b, skip c
+	_, err = o.LoadConfig(config)
+	assert.Nil(err, name)
+	_, err = o.HandleHotkey(" ")
+	assert.Nil(err, name)
+	_, err = o.HandleHotkey("x")
+	assert.Nil(err, name)
+	_, err = o.HandleHotkey(" ")
+	assert.Nil(err, name)
+	_, err = o.DropConfigWait()
+	assert.Nil(err, name)
+	assert.FileExists(filepath.Join(dir2, "b.txt"), name)
+
+	// Resume after a, c with d as the current file
+	o = NewOrganizerWithJournal(journal)
+	o.sessionPath = sessionPath
+	status, err := o.ResumeSession()
+	assert.Nil(err, name)
+	assert.Equal(3, status.NumFiles, name)
+	assert.Equal(2, status.CurrentFileIndex, name)
+	assert.Equal("d.txt", status.CurrentFile.Name, name)
+	assert.Equal(int64(3), status.CurrentFile.ID, name)
+
+	// Restored decisions can be undone
+	status, err = o.Undo()
+	assert.Nil(err, name)
+	assert.Equal(1, status.CurrentFileIndex, name)
+	assert.Equal("c.txt", status.CurrentFile.Name, name)
+
+	// Resume again after a
+	status, err = o.ResumeSession()
+	assert.Nil(err, name)
+	assert.Equal(1, status.CurrentFileIndex, name)
+	assert.Equal("c.txt", status.CurrentFile.Name, name)
+
+	_, err = o.DropConfigWait()
+	assert.Nil(err, name)
+}
+
+func TestOrganizerInteractionLatestSession(t *testing.T) {
+	assert := assert.New(t)
+	name := "TestOrganizerInteractionLatestSession"
+
+	dir1, err := ioutil.TempDir("", "dir")
+	assert.Nil(err)
+	defer os.RemoveAll(dir1)
+	for _, n := range []string{"a.txt", "b.txt"} {
+		err = ioutil.WriteFile(filepath.Join(dir1, n), []byte("123"), 0644)
+		assert.Nil(err)
+	}
+
+	dir2, err := ioutil.TempDir("", "dir")
+	assert.Nil(err)
+	defer os.RemoveAll(dir2)
+
+	dir3, err := ioutil.TempDir("", "session")
+	assert.Nil(err)
+	defer os.RemoveAll(dir3)
+
+	journal := NewJournal(filepath.Join(dir3, defaultJournalFilename))
+	sessionPath := filepath.Join(dir3, defaultSessionFilename)
+	config := configWithSrcDirAndDstDir(dir1, dir2)
+	config.Name = "old"
+
+	// No session
+	o := NewOrganizerWithJournal(journal)
+	o.sessionPath = sessionPath
+	session, err := o.LatestSession()
+	assert.Nil(err, name)
+	assert.Nil(session, name)
+
+	_, err = o.LoadConfig(config)
+	assert.Nil(err, name)
+	_, err = o.HandleHotkey(" ")
+	assert.Nil(err, name)
+	_, err = o.DropConfigWait()
+	assert.Nil(err, name)
+
+	// The session of a previous run is not overwritten by a new configuration
+	o = NewOrganizerWithJournal(journal)
+	o.sessionPath = sessionPath
+	newConfig := configWithSrcDirAndDstDir(dir1, dir2)
+	newConfig.Name = "new"
+	_, err = o.LoadConfig(newConfig)
+	assert.Nil(err, name)
+	session, err = o.LatestSession()
+	assert.Nil(err, name)
+	assert.Equal("old", session.Config.Name, name)
+	assert.Len(session.Decisions, 1, name)
+
+	// Once discarded, the session is replaced by the current one
+	err = o.DiscardSession()
+	assert.Nil(err, name)
+	session, err = o.LatestSession()
+	assert.Nil(err, name)
+	assert.Equal("new", session.Config.Name, name)
+	assert.Empty(session.Decisions, name)
+
+	_, err = o.DropConfigWait()
+	assert.Nil(err, name)
+}
+
+func TestOrganizerInteractionResumeSessionExecuted(t *testing.T) {
+	assert := assert.New(t)
+	name := "TestOrganizerInteractionResumeSessionExecuted"
+
+	dir1, err := ioutil.TempDir("", "dir")
+	assert.Nil(err)
+	defer os.RemoveAll(dir1)
+	for _, n := range []string{"a.txt", "b.txt", "c.txt"} {
+		err = ioutil.WriteFile(filepath.Join(dir1, n), []byte("123"), 0644)
+		assert.Nil(err)
+	}
+
+	dir2, err := ioutil.TempDir("", "dir")
+	assert.Nil(err)
+	defer os.RemoveAll(dir2)
+
+	dir3, err := ioutil.TempDir("", "session")
+	assert.Nil(err)
+	defer os.RemoveAll(dir3)
+
+	journal := NewJournal(filepath.Join(dir3, defaultJournalFilename))
+	sessionPath := filepath.Join(dir3, defaultSessionFilename)
+	config := configWithSrcDirAndDstDir(dir1, dir2)
+	config.Name = "session"
+
+	// Skip a, copy b
+	o := NewOrganizerWithJournal(journal)
+	o.sessionPath = sessionPath
+	_, err = o.LoadConfig(config)
+	assert.Nil(err, name)
+	_, err = o.HandleHotkey(" ")
+	assert.Nil(err, name)
+	_, err = o.HandleHotkey("x")
+	assert.Nil(err, name)
+	_, err = o.DropConfigWait()
+	assert.Nil(err, name)
+	assert.FileExists(filepath.Join(dir2, "b.txt"), name)
+
+	o = NewOrganizerWithJournal(journal)
+	o.sessionPath = sessionPath
+	status, err := o.ResumeSession()
+	assert.Nil(err, name)
+	assert.Equal(2, status.CurrentFileIndex, name)
+
+	// Executed decisions cannot be undone
+	_, err = o.Undo()
+	assert.NotNil(err, name)
+
+	// b is not sent again
+	status, err = o.GoToFile(1)
+	assert.Nil(err, name)
+	assert.Equal("b.txt", status.CurrentFile.Name, name)
+	assert.Len(status.CurrentFileOperations, 1, name)
+	assert.Equal(OpStatusSucceeded, status.CurrentFileOperations[0].Status, name)
+	assert.Equal(filepath.Join(dir2, "b.txt"), status.CurrentFileOperations[0].FinalDstPath, name)
+	status, err = o.HandleHotkey("x")
+	assert.Nil(err, name)
+	assert.Equal(1, status.CurrentFileIndex, name)
+	assert.Empty(o.organizer.operations, name)
+
+	// The record survives another resume
+	_, err = o.DropConfigWait()
+	assert.Nil(err, name)
+	o = NewOrganizerWithJournal(journal)
+	o.sessionPath = sessionPath
+	_, err = o.ResumeSession()
+	assert.Nil(err, name)
+	status, err = o.GoToFile(1)
+	assert.Nil(err, name)
+	assert.Len(status.CurrentFileOperations, 1, name)
+	recordID := status.CurrentFileOperations[0].ID
+
+	// New operations do not reuse the IDs of the records
+	_, err = o.GoToFile(0)
+	assert.Nil(err, name)
+	status, err = o.HandleHotkey("x")
+	assert.Nil(err, name)
+	status, err = o.GoToFile(0)
+	assert.Nil(err, name)
+	assert.Len(status.CurrentFileOperations, 1, name)
+	assert.NotEqual(recordID, status.CurrentFileOperations[0].ID, name)
+
+	_, err = o.DropConfigWait()
+	assert.Nil(err, name)
+	_, err = os.Stat(filepath.Join(dir2, "b (1).txt"))
+	assert.True(os.IsNotExist(err), name)
+	names, err := ioutil.ReadDir(dir2)
+	assert.Nil(err, name)
+	assert.Len(names, 2, name)
+}
+
+func TestOrganizerInteractionResumeSessionRandomOrder(t *testing.T) {
+	assert := assert.New(t)
+	name := "TestOrganizerInteractionResumeSessionRandomOrder"
+
+	dir1, err := ioutil.TempDir("", "dir")
+	assert.Nil(err)
+	defer os.RemoveAll(dir1)
+	for i := 0; i < 20; i++ {
+		err = ioutil.WriteFile(filepath.Join(dir1, fmt.Sprintf("%02d.txt", i)), []byte("123"), 0644)
+		assert.Nil(err)
+	}
+
+	dir2, err := ioutil.TempDir("", "dir")
+	assert.Nil(err)
+	defer os.RemoveAll(dir2)
+
+	dir3, err := ioutil.TempDir("", "session")
+	assert.Nil(err)
+	defer os.RemoveAll(dir3)
+
+	journal := NewJournal(filepath.Join(dir3, defaultJournalFilename))
+	sessionPath := filepath.Join(dir3, defaultSessionFilename)
+	config := configWithSrcDirAndDstDir(dir1, dir2)
+	config.Name = "session"
+	config.Src.Sort = SrcSortRandom
+
+	fileNames := func(o *Organizer) []string {
+		names := []string{}
+		for _, f := range o.organizer.files {
+			names = append(names, f.Name)
+		}
+		return names
+	}
+
+	o := NewOrganizerWithJournal(journal)
+	o.sessionPath = sessionPath
+	_, err = o.LoadConfig(config)
+	assert.Nil(err, name)
+	assert.Zero(config.Src.SortSeed, name)
+	seed := o.organizer.sortSeed
+	assert.True(seed > 0 && seed <= maxSortSeed, name)
+	want := fileNames(o)
+	_, err = o.HandleHotkey(" ")
+	assert.Nil(err, name)
+	_, err = o.DropConfigWait()
+	assert.Nil(err, name)
+
+	o = NewOrganizerWithJournal(journal)
+	o.sessionPath = sessionPath
+	status, err := o.ResumeSession()
+	assert.Nil(err, name)
+	assert.Equal(1, status.CurrentFileIndex, name)
+	assert.Equal(want, fileNames(o), name)
+	assert.Equal(seed, o.organizer.sortSeed, name)
+	assert.Zero(status.Config.Src.SortSeed, name)
+
+	_, err = o.DropConfigWait()
+	assert.Nil(err, name)
+}
+
+func TestOrganizerInteractionNavigation(t *testing.T) {
+	assert := assert.New(t)
+	name := "TestOrganizerInteractionNavigation"
+
+	dir1, err := ioutil.TempDir("", "dir")
+	assert.Nil(err)
+	defer os.RemoveAll(dir1)
+	for _, n := range []string{"a.txt", "b.jpg", "c.txt"} {
+		err = ioutil.WriteFile(filepath.Join(dir1, n), []byte("123"), 0644)
+		assert.Nil(err)
+	}
+
+	dir2, err := ioutil.TempDir("", "dir")
+	assert.Nil(err)
+	defer os.RemoveAll(dir2)
+
+	o := NewOrganizer()
+
+	// No config
+	status, err := o.PreviousFile()
+	assert.Nil(err, name)
+	assert.Equal(&OrganizerStatus{}, status, name)
+	_, err = o.GoToFile(0)
+	assert.NotNil(err, name)
+	_, err = o.GoToFileByName("*")
+	assert.NotNil(err, name)
+
+	_, err = o.LoadConfig(configWithSrcDirAndDstDir(dir1, dir2))
+	assert.Nil(err, name)
+
+	// Already at the first file
+	status, err = o.PreviousFile()
+	assert.Nil(err, name)
+	assert.Equal(0, status.CurrentFileIndex, name)
+
+	// Send a.txt, then go back to it
+	status, err = o.HandleHotkey("x")
+	assert.Nil(err, name)
+	assert.Equal(1, status.CurrentFileIndex, name)
+	assert.Empty(status.CurrentFileOperations, name)
+	status, err = o.PreviousFile()
+	assert.Nil(err, name)
+	assert.Equal(0, status.CurrentFileIndex, name)
+	assert.Len(status.CurrentFileOperations, 1, name)
+	assert.Equal(filepath.Join(dir2, "a.txt"), status.CurrentFileOperations[0].DstPath, name)
+
+	// Already sent file is not offered again
+	status, err = o.HandleHotkey("x")
+	assert.Nil(err, name)
+	assert.Equal(0, status.CurrentFileIndex, name)
+	assert.Equal(1, status.Operations.NumQueued+status.Operations.NumRunning+status.Operations.NumSucceeded, name)
+
+	// Go to file by index
+	_, err = o.GoToFile(-1)
+	assert.NotNil(err, name)
+	_, err = o.GoToFile(3)
+	assert.NotNil(err, name)
+	status, err = o.GoToFile(2)
+	assert.Nil(err, name)
+	assert.Equal("c.txt", status.CurrentFile.Name, name)
+
+	// Go to file by name, wrapping around
+	status, err = o.GoToFileByName("*.txt")
+	assert.Nil(err, name)
+	assert.Equal("a.txt", status.CurrentFile.Name, name)
+	status, err = o.GoToFileByName("*.jpg")
+	assert.Nil(err, name)
+	assert.Equal("b.jpg", status.CurrentFile.Name, name)
+	_, err = o.GoToFileByName("*.png")
+	assert.NotNil(err, name)
+	_, err = o.GoToFileByName("[")
+	assert.NotNil(err, name)
+
+	_, err = o.DropConfigWait()
+	assert.Nil(err, name)
+}
+
+func TestOrganizerInteractionDeferred(t *testing.T) {
+	assert := assert.New(t)
+	name := "TestOrganizerInteractionDeferred"
+
+	dir1, err := ioutil.TempDir("", "dir")
+	assert.Nil(err)
+	defer os.RemoveAll(dir1)
+	for _, n := range []string{"a.txt", "b.txt"} {
+		err = ioutil.WriteFile(filepath.Join(dir1, n), []byte("123"), 0644)
+		assert.Nil(err)
+	}
+
+	dir2, err := ioutil.TempDir("", "dir")
+	assert.Nil(err)
+	defer os.RemoveAll(dir2)
+
+	dir3, err := ioutil.TempDir("", "dir")
+	assert.Nil(err)
+	defer os.RemoveAll(dir3)
+
+	dir4, err := ioutil.TempDir("", "session")
+	assert.Nil(err)
+	defer os.RemoveAll(dir4)
+
+	config := configWithSrcDirAndDstDirMove(dir1, dir2)
+	config.Name = "deferred"
+	config.Dst.Dirs = append(config.Dst.Dirs, &DstDir{Hotkey: "y", Dir: dir3})
+	config.Ops.Deferred = true
+
+	o := NewOrganizerWithJournal(NewJournal(filepath.Join(dir4, defaultJournalFilename)))
+	o.sessionPath = filepath.Join(dir4, defaultSessionFilename)
+
+	// Nothing to commit without config
+	_, err = o.CommitDecisions()
+	assert.NotNil(err, name)
+
+	_, err = o.LoadConfig(config)
+	assert.Nil(err, name)
+
+	// Stage a.txt to x, nothing touches the disk
+	status, err := o.HandleHotkey("x")
+	assert.Nil(err, name)
+	assert.Equal(1, status.Operations.NumStaged, name)
+	time.Sleep(300 * time.Millisecond)
+	assert.FileExists(filepath.Join(dir1, "a.txt"), name)
+
+	// Change decision to y
+	status, err = o.PreviousFile()
+	assert.Nil(err, name)
+	assert.Len(status.CurrentFileOperations, 1, name)
+	assert.Equal(OpStatusStaged, status.CurrentFileOperations[0].Status, name)
+	status, err = o.HandleHotkey("y")
+	assert.Nil(err, name)
+	assert.Equal(1, status.Operations.NumStaged, name)
+
+	// Change decision to skip, then undo it
+	_, err = o.PreviousFile()
+	assert.Nil(err, name)
+	status, err = o.HandleHotkey(" ")
+	assert.Nil(err, name)
+	assert.Equal(0, status.Operations.NumStaged, name)
+	status, err = o.Undo()
+	assert.Nil(err, name)
+	assert.Equal(1, status.Operations.NumStaged, name)
+	assert.Equal(filepath.Join(dir3, "a.txt"), status.CurrentFileOperations[0].DstPath, name)
+
+	// Stage b.txt to x, staged decisions survive a resume
+	_, err = o.GoToFile(1)
+	assert.Nil(err, name)
+	_, err = o.HandleHotkey("x")
+	assert.Nil(err, name)
+	status, err = o.ResumeSession()
+	assert.Nil(err, name)
+	assert.Equal(2, status.Operations.NumStaged, name)
+
+	// Commit decisions
+	status, err = o.CommitDecisions()
+	assert.Nil(err, name)
+	assert.Equal(0, status.Operations.NumStaged, name)
+	_, err = o.DropConfigWait()
+	assert.Nil(err, name)
+	assert.FileExists(filepath.Join(dir3, "a.txt"), name)
+	assert.FileExists(filepath.Join(dir2, "b.txt"), name)
+	_, err = os.Stat(filepath.Join(dir2, "a.txt"))
+	assert.True(os.IsNotExist(err), name)
+}
+
+func TestOrganizerInteractionDryRun(t *testing.T) {
+	assert := assert.New(t)
+	name := "TestOrganizerInteractionDryRun"
+
+	dir1, err := ioutil.TempDir("", "dir")
+	assert.Nil(err)
+	defer os.RemoveAll(dir1)
+	for _, n := range []string{"a.txt", "b.txt"} {
+		err = ioutil.WriteFile(filepath.Join(dir1, n), []byte("123"), 0644)
+		assert.Nil(err)
+	}
+
+	dir2, err := ioutil.TempDir("", "dir")
+	assert.Nil(err)
+	defer os.RemoveAll(dir2)
+	err = ioutil.WriteFile(filepath.Join(dir2, "b.txt"), []byte("456"), 0644)
+	assert.Nil(err)
+
+	config := configWithSrcDirAndDstDirMove(dir1, dir2)
+	config.Ops.DryRun = true
+
+	o := NewOrganizer()
+
+	// No plan without config
+	_, err = o.ExecutionPlan()
+	assert.NotNil(err, name)
+
+	_, err = o.LoadConfig(config)
+	assert.Nil(err, name)
+	_, err = o.HandleHotkey("x")
+	assert.Nil(err, name)
+	status, err := o.HandleHotkey("x")
+	assert.Nil(err, name)
+	assert.Equal(2, status.Operations.NumStaged, name)
+
+	plan, err := o.ExecutionPlan()
+	assert.Nil(err, name)
+	assert.Len(plan.Steps, 2, name)
+	assert.Equal(filepath.Join(dir1, "a.txt"), plan.Steps[0].SrcPath, name)
+	assert.Equal(OpTypeMove, plan.Steps[0].Op, name)
+	assert.Equal(filepath.Join(dir2, "a.txt"), plan.Steps[0].PredictedDstPath, name)
+	assert.False(plan.Steps[0].Collision, name)
+	assert.Equal(filepath.Join(dir2, "b(1).txt"), plan.Steps[1].PredictedDstPath, name)
+	assert.True(plan.Steps[1].Collision, name)
+
+	// Dry run operations are never executed
+	_, err = o.CommitDecisions()
+	assert.NotNil(err, name)
+	_, err = o.DropConfigWait()
+	assert.Nil(err, name)
+	assert.FileExists(filepath.Join(dir1, "a.txt"), name)
+	assert.FileExists(filepath.Join(dir1, "b.txt"), name)
+	_, err = os.Stat(filepath.Join(dir2, "a.txt"))
+	assert.True(os.IsNotExist(err), name)
+}
+
+func TestOrganizerInteractionExportImportPlan(t *testing.T) {
+	assert := assert.New(t)
+	name := "TestOrganizerInteractionExportImportPlan"
+
+	dir1, err := ioutil.TempDir("", "dir")
+	assert.Nil(err)
+	defer os.RemoveAll(dir1)
+	for _, n := range []string{"a.txt", "b.txt"} {
+		err = ioutil.WriteFile(filepath.Join(dir1, n), []byte("123"), 0644)
+		assert.Nil(err)
+	}
+
+	dir2, err := ioutil.TempDir("", "dir")
+	assert.Nil(err)
+	defer os.RemoveAll(dir2)
+
+	o := NewOrganizer()
+
+	// No plan without config
+	_, err = o.ExportPlanScript()
+	assert.NotNil(err, name)
+	_, err = o.ExportPlanCSV()
+	assert.NotNil(err, name)
+	_, err = o.ImportPlanCSV("src,dst,op\n")
+	assert.NotNil(err, name)
+
+	// Record a dry run
+	config := configWithSrcDirAndDstDirMove(dir1, dir2)
+	config.Ops.DryRun = true
+	_, err = o.LoadConfig(config)
+	assert.Nil(err, name)
+	_, err = o.HandleHotkey("x")
+	assert.Nil(err, name)
+	_, err = o.HandleHotkey("x")
+	assert.Nil(err, name)
+
+	script, err := o.ExportPlanScript()
+	assert.Nil(err, name)
+	assert.Contains(script, "mv -- '"+filepath.Join(dir1, "a.txt")+"' '"+filepath.Join(dir2, "a.txt")+"'", name)
+
+	plan, err := o.ExportPlanCSV()
+	assert.Nil(err, name)
+	_, err = o.ImportPlanCSV(plan)
+	assert.NotNil(err, name)
+
+	// Execute the plan
+	config.Ops.DryRun = false
+	_, err = o.LoadConfig(config)
+	assert.Nil(err, name)
+	_, err = o.ImportPlanCSV("src,dst\n")
+	assert.NotNil(err, name)
+	status, err := o.ImportPlanCSV(plan)
+	assert.Nil(err, name)
+	assert.Equal(2, status.Operations.NumQueued, name)
+	assert.Equal(0, status.CurrentFileIndex, name)
+
+	_, err = o.DropConfigWait()
+	assert.Nil(err, name)
+	assert.FileExists(filepath.Join(dir2, "a.txt"), name)
+	assert.FileExists(filepath.Join(dir2, "b.txt"), name)
+}
+
+func TestOrganizerInteractionDstDirOpType(t *testing.T) {
+	assert := assert.New(t)
+	name := "TestOrganizerInteractionDstDirOpType"
+
+	dir1, err := ioutil.TempDir("", "dir")
+	assert.Nil(err)
+	defer os.RemoveAll(dir1)
+	for _, n := range []string{"a.txt", "b.txt"} {
+		err = ioutil.WriteFile(filepath.Join(dir1, n), []byte("123"), 0644)
+		assert.Nil(err)
+	}
+
+	dir2, err := ioutil.TempDir("", "dir")
+	assert.Nil(err)
+	defer os.RemoveAll(dir2)
+
+	dir3, err := ioutil.TempDir("", "dir")
+	assert.Nil(err)
+	defer os.RemoveAll(dir3)
+
+	config := configWithSrcDirAndDstDirMove(dir1, dir2)
+	config.Dst.Dirs = append(config.Dst.Dirs, &DstDir{Hotkey: "b", Dir: dir3, OpType: OpTypeCopy})
+
+	o := NewOrganizer()
+	_, err = o.LoadConfig(config)
+	assert.Nil(err, name)
+
+	// Copy a.txt to backup, move b.txt with the default op type
+	_, err = o.HandleHotkey("b")
+	assert.Nil(err, name)
+	_, err = o.HandleHotkey("x")
+	assert.Nil(err, name)
+	_, err = o.DropConfigWait()
+	assert.Nil(err, name)
+
+	assert.FileExists(filepath.Join(dir1, "a.txt"), name)
+	assert.FileExists(filepath.Join(dir3, "a.txt"), name)
+	assert.FileExists(filepath.Join(dir2, "b.txt"), name)
+	_, err = os.Stat(filepath.Join(dir1, "b.txt"))
+	assert.True(os.IsNotExist(err), name)
+}
+
+func TestOrganizerInteractionShiftHotkey(t *testing.T) {
+	assert := assert.New(t)
+	name := "TestOrganizerInteractionShiftHotkey"
+
+	dir1, err := ioutil.TempDir("", "dir")
+	assert.Nil(err)
+	defer os.RemoveAll(dir1)
+	for _, n := range []string{"a.txt", "b.txt", "c.txt"} {
+		err = ioutil.WriteFile(filepath.Join(dir1, n), []byte("123"), 0644)
+		assert.Nil(err)
+	}
+
+	dir2, err := ioutil.TempDir("", "dir")
+	assert.Nil(err)
+	defer os.RemoveAll(dir2)
+
+	dir3, err := ioutil.TempDir("", "dir")
+	assert.Nil(err)
+	defer os.RemoveAll(dir3)
+
+	config := configWithSrcDirAndDstDirMove(dir1, dir2)
+	config.Dst.Dirs = append(config.Dst.Dirs, &DstDir{Hotkey: "Shift+y", Dir: dir3})
+
+	o := NewOrganizer()
+	_, err = o.LoadConfig(config)
+	assert.Nil(err, name)
+
+	// Copy a.txt inverting the default move, move b.txt,
+	// move c.txt to the dir associated to the modified hotkey
+	_, err = o.HandleHotkeyEvent(&HotkeyEvent{Key: "x", Shift: true})
+	assert.Nil(err, name)
+	_, err = o.HandleHotkey("x")
+	assert.Nil(err, name)
+	_, err = o.HandleHotkey("Shift+y")
+	assert.Nil(err, name)
+	_, err = o.DropConfigWait()
+	assert.Nil(err, name)
+
+	assert.FileExists(filepath.Join(dir1, "a.txt"), name)
+	assert.FileExists(filepath.Join(dir2, "a.txt"), name)
+	assert.FileExists(filepath.Join(dir2, "b.txt"), name)
+	assert.FileExists(filepath.Join(dir3, "c.txt"), name)
+	_, err = os.Stat(filepath.Join(dir1, "b.txt"))
+	assert.True(os.IsNotExist(err), name)
+	_, err = os.Stat(filepath.Join(dir1, "c.txt"))
+	assert.True(os.IsNotExist(err), name)
+}
+
+func TestOrganizerInteractionShiftedCharHotkey(t *testing.T) {
+	assert := assert.New(t)
+	name := "TestOrganizerInteractionShiftedCharHotkey"
+
+	dir1, err := ioutil.TempDir("", "dir")
+	assert.Nil(err)
+	defer os.RemoveAll(dir1)
+	for _, n := range []string{"a.txt", "b.txt", "c.txt", "d.txt"} {
+		err = ioutil.WriteFile(filepath.Join(dir1, n), []byte("123"), 0644)
+		assert.Nil(err)
+	}
+
+	dirLower, err := ioutil.TempDir("", "dir")
+	assert.Nil(err)
+	defer os.RemoveAll(dirLower)
+
+	dirUpper, err := ioutil.TempDir("", "dir")
+	assert.Nil(err)
+	defer os.RemoveAll(dirUpper)
+
+	dirSymbol, err := ioutil.TempDir("", "dir")
+	assert.Nil(err)
+	defer os.RemoveAll(dirSymbol)
+
+	config := configWithSrcDirAndDstDirMove(dir1, dirLower)
+	config.Dst.Dirs = []*DstDir{
+		{Hotkey: "a", Dir: dirLower},
+		{Hotkey: "A", Dir: dirUpper},
+		{Hotkey: "!", Dir: dirSymbol},
+	}
+
+	o := NewOrganizer()
+	_, err = o.LoadConfig(config)
+	assert.Nil(err, name)
+
+	// Move a.txt with "a", move b.txt with "A" typed with shift,
+	// move c.txt with "!" typed with shift, move d.txt with "a"
+	_, err = o.HandleHotkeyEvent(&HotkeyEvent{Key: "a", Raw: "a"})
+	assert.Nil(err, name)
+	_, err = o.HandleHotkeyEvent(&HotkeyEvent{Key: "a", Shift: true, Raw: "A"})
+	assert.Nil(err, name)
+	_, err = o.HandleHotkeyEvent(&HotkeyEvent{Key: "!", Shift: true, Raw: "!"})
+	assert.Nil(err, name)
+	_, err = o.HandleHotkey("a")
+	assert.Nil(err, name)
+	_, err = o.DropConfigWait()
+	assert.Nil(err, name)
+
+	assert.FileExists(filepath.Join(dirLower, "a.txt"), name)
+	assert.FileExists(filepath.Join(dirUpper, "b.txt"), name)
+	assert.FileExists(filepath.Join(dirSymbol, "c.txt"), name)
+	assert.FileExists(filepath.Join(dirLower, "d.txt"), name)
+	for _, n := range []string{"a.txt", "b.txt", "c.txt", "d.txt"} {
+		_, err = os.Stat(filepath.Join(dir1, n))
+		assert.True(os.IsNotExist(err), name)
+	}
+
+	// Without a destination for the typed character, shift inverts the operation
+	config.Dst.Dirs = config.Dst.Dirs[:1]
+	err = ioutil.WriteFile(filepath.Join(dir1, "e.txt"), []byte("123"), 0644)
+	assert.Nil(err)
+	o = NewOrganizer()
+	_, err = o.LoadConfig(config)
+	assert.Nil(err, name)
+	_, err = o.HandleHotkeyEvent(&HotkeyEvent{Key: "a", Shift: true, Raw: "A"})
+	assert.Nil(err, name)
+	_, err = o.DropConfigWait()
+	assert.Nil(err, name)
+
+	assert.FileExists(filepath.Join(dir1, "e.txt"), name)
+	assert.FileExists(filepath.Join(dirLower, "e.txt"), name)
+}
+
+func TestOrganizerInteractionMultipleDstDirs(t *testing.T) {
+	assert := assert.New(t)
+	name := "TestOrganizerInteractionMultipleDstDirs"
+
+	dir1, err := ioutil.TempDir("", "dir")
+	assert.Nil(err)
+	defer os.RemoveAll(dir1)
+	for _, n := range []string{"a.txt", "b.txt", "c.txt"} {
+		err = ioutil.WriteFile(filepath.Join(dir1, n), []byte("123"), 0644)
+		assert.Nil(err)
+	}
+
+	dir2, err := ioutil.TempDir("", "dir")
+	assert.Nil(err)
+	defer os.RemoveAll(dir2)
+
+	dir3, err := ioutil.TempDir("", "dir")
+	assert.Nil(err)
+	defer os.RemoveAll(dir3)
+
+	dir4, err := ioutil.TempDir("", "dir")
+	assert.Nil(err)
+	defer os.RemoveAll(dir4)
+
+	// c.txt cannot be copied to dir4
+	err = ioutil.WriteFile(filepath.Join(dir4, "c.txt"), []byte("456"), 0644)
+	assert.Nil(err)
+
+	config := configWithSrcDirAndDstDirMove(dir1, dir2)
+	config.Dst.Dirs = append(config.Dst.Dirs,
+		&DstDir{Hotkey: "y", Dir: dir3},
+		&DstDir{Hotkey: "z", Dir: dir4},
+	)
+	config.Ops.NumWorkers = 3
+	config.Ops.MaxTries = 0
+
+	o := NewOrganizer()
+	_, err = o.LoadConfig(config)
+	assert.Nil(err, name)
+
+	// Same destination twice, ignored
+	status, err := o.HandleHotkeys([]string{"x", "x"})
+	assert.Nil(err, name)
+	assert.Equal(0, status.CurrentFileIndex, name)
+
+	// Copy a.txt to dir2 and dir3 and then move it to dir4
+	status, err = o.HandleHotkeys([]string{"x", "y", "z"})
+	assert.Nil(err, name)
+	assert.Equal(1, status.CurrentFileIndex, name)
+
+	// Copy b.txt to dir4 and dir3 and undo,
+	// then copy it to dir3 and move it to dir4
+	_, err = o.HandleHotkeys([]string{"z", "Shift+y"})
+	assert.Nil(err, name)
+	_, err = o.Undo()
+	assert.Nil(err, name)
+	_, err = o.HandleHotkeys([]string{"y", "z"})
+	assert.Nil(err, name)
+
+	// The copy of c.txt to dir4 fails, so c.txt is not moved to dir2
+	_, err = o.HandleHotkeys([]string{"z", "x"})
+	assert.Nil(err, name)
+
+	_, err = o.DropConfigWait()
+	assert.Nil(err, name)
+
+	for _, p := range []string{
+		filepath.Join(dir2, "a.txt"),
+		filepath.Join(dir3, "a.txt"),
+		filepath.Join(dir4, "a.txt"),
+		filepath.Join(dir3, "b.txt"),
+		filepath.Join(dir4, "b.txt"),
+		filepath.Join(dir1, "c.txt"),
+	} {
+		assert.FileExists(p, name)
+	}
+	for _, p := range []string{
+		filepath.Join(dir1, "a.txt"),
+		filepath.Join(dir1, "b.txt"),
+		filepath.Join(dir2, "c.txt"),
+	} {
+		_, err = os.Stat(p)
+		assert.True(os.IsNotExist(err), name)
+	}
+}
+
+func TestOrganizerInteractionCollisionPolicy(t *testing.T) {
+	assert := assert.New(t)
+
+	tests := []struct {
+		name        string
+		policy      CollisionPolicy
+		existing    string
+		wantOutcome OpOutcome
+		wantDst     string
+		wantSrc     bool
+	}{
+		{"rename", CollisionPolicyRename, "456", OpOutcomeRenamed, "456", false},
+		{"skip", CollisionPolicySkip, "456", OpOutcomeSkipped, "456", true},
+		{"overwrite", CollisionPolicyOverwrite, "456", OpOutcomeOverwritten, "123", false},
+		{"newer", CollisionPolicyNewer, "456", OpOutcomeOverwritten, "123", false},
+		{"larger", CollisionPolicyLarger, "4567", OpOutcomeSkipped, "4567", true},
+		{"identical", CollisionPolicyIdentical, "123", OpOutcomeIdentical, "123", true},
+	}
+	for _, tt := range tests {
+		dir1, err := ioutil.TempDir("", "dir")
+		assert.Nil(err)
+		defer os.RemoveAll(dir1)
+		err = ioutil.WriteFile(filepath.Join(dir1, "a.txt"), []byte("123"), 0644)
+		assert.Nil(err)
+
+		dir2, err := ioutil.TempDir("", "dir")
+		assert.Nil(err)
+		defer os.RemoveAll(dir2)
+		dstPath := filepath.Join(dir2, "a.txt")
+		err = ioutil.WriteFile(dstPath, []byte(tt.existing), 0644)
+		assert.Nil(err)
+		old := time.Now().Add(-time.Hour)
+		err = os.Chtimes(dstPath, old, old)
+		assert.Nil(err)
+
+		config := configWithSrcDirAndDstDirMove(dir1, dir2)
+		config.Ops.CollisionPolicy = tt.policy
+
+		o := NewOrganizer()
+		_, err = o.LoadConfig(config)
+		assert.Nil(err, tt.name)
+		_, err = o.HandleHotkey("x")
+		assert.Nil(err, tt.name)
+		op := o.organizer.operations[0]
+		_, err = o.DropConfigWait()
+		assert.Nil(err, tt.name)
+
+		assert.Equal(OpStatusSucceeded, op.Status, tt.name)
+		assert.Equal(tt.wantOutcome, op.Outcome, tt.name)
+		content, err := ioutil.ReadFile(dstPath)
+		assert.Nil(err, tt.name)
+		assert.Equal(tt.wantDst, string(content), tt.name)
+		_, err = os.Stat(filepath.Join(dir1, "a.txt"))
+		assert.Equal(tt.wantSrc, err == nil, tt.name)
+	}
+}
+
+func TestOrganizerInteractionSameNameConcurrentWorkers(t *testing.T) {
+	assert := assert.New(t)
+
+	const numFiles = 20
+	for _, policy := range []CollisionPolicy{CollisionPolicyRename, CollisionPolicyOverwrite, CollisionPolicySkip} {
+		name := "TestOrganizerInteractionSameNameConcurrentWorkers " + string(policy)
+
+		dir1, err := ioutil.TempDir("", "dir")
+		assert.Nil(err)
+		defer os.RemoveAll(dir1)
+		for i := 0; i < numFiles; i++ {
+			subdir := filepath.Join(dir1, fmt.Sprintf("sub%02d", i))
+			err = os.Mkdir(subdir, 0755)
+			assert.Nil(err)
+			err = ioutil.WriteFile(filepath.Join(subdir, "IMG_0001.jpg"), []byte(subdir), 0644)
+			assert.Nil(err)
+		}
+
+		dir2, err := ioutil.TempDir("", "dir")
+		assert.Nil(err)
+		defer os.RemoveAll(dir2)
+
+		config := configWithSrcDirAndDstDirMove(dir1, dir2)
+		config.Src.IncludeSubdirs = true
+		config.Ops.NumWorkers = 5
+		config.Ops.MaxTries = numFiles
+		config.Ops.CollisionPolicy = policy
+
+		o := NewOrganizer()
+		_, err = o.LoadConfig(config)
+		assert.Nil(err, name)
+		for i := 0; i < numFiles; i++ {
+			_, err = o.HandleHotkey("x")
+			assert.Nil(err, name)
+		}
+
+		// Destinations are reserved when operations are created
+		o.organizer.opsMutex.Lock()
+		dstPaths := make(map[string]bool)
+		for _, op := range o.organizer.operations {
+			dstPaths[op.DstPath] = true
+		}
+		o.organizer.opsMutex.Unlock()
+		assert.Len(dstPaths, numFiles, name)
+
+		_, err = o.DropConfigWait()
+		assert.Nil(err, name)
+
+		// Every file is moved to its own destination
+		infos, err := ioutil.ReadDir(dir2)
+		assert.Nil(err, name)
+		assert.Len(infos, numFiles, name)
+		contents := make(map[string]bool)
+		for _, info := range infos {
+			content, err := ioutil.ReadFile(filepath.Join(dir2, info.Name()))
+			assert.Nil(err, name)
+			contents[string(content)] = true
+		}
+		assert.Len(contents, numFiles, name)
+	}
+}
+
+func TestOrganizerInteractionTrash(t *testing.T) {
+	assert := assert.New(t)
+	name := "TestOrganizerInteractionTrash"
+
+	dataHome, err := ioutil.TempDir("", "data")
+	assert.Nil(err)
+	defer os.RemoveAll(dataHome)
+	prev, ok := os.LookupEnv("XDG_DATA_HOME")
+	defer func() {
+		if ok {
+			os.Setenv("XDG_DATA_HOME", prev)
+		} else {
+			os.Unsetenv("XDG_DATA_HOME")
+		}
+	}()
+	os.Setenv("XDG_DATA_HOME", dataHome)
+	trash := filepath.Join(dataHome, "Trash")
+
+	dir1, err := ioutil.TempDir("", "dir")
+	assert.Nil(err)
+	defer os.RemoveAll(dir1)
+	for _, n := range []string{"a.txt", "b.txt"} {
+		err = ioutil.WriteFile(filepath.Join(dir1, n), []byte("123"), 0644)
+		assert.Nil(err)
+	}
+
+	dir2, err := ioutil.TempDir("", "dir")
+	assert.Nil(err)
+	defer os.RemoveAll(dir2)
+
+	config := configWithSrcDirAndDstDirMove(dir1, dir2)
+	config.Dst.Dirs = append(config.Dst.Dirs, &DstDir{Hotkey: "d", OpType: OpTypeTrash})
+
+	o := NewOrganizer()
+	_, err = o.LoadConfig(config)
+	assert.Nil(err, name)
+
+	// Trash a.txt and restore it
+	_, err = o.HandleHotkey("d")
+	assert.Nil(err, name)
+	_, err = o.Undo()
+	assert.Nil(err, name)
+	assert.FileExists(filepath.Join(dir1, "a.txt"), name)
+	_, err = os.Stat(filepath.Join(trash, trashFilesDir, "a.txt"))
+	assert.True(os.IsNotExist(err), name)
+	_, err = os.Stat(filepath.Join(trash, trashInfoDir, "a.txt"+trashInfoExt))
+	assert.True(os.IsNotExist(err), name)
+
+	// Copy a.txt to dir2 and trash it, trash b.txt
+	_, err = o.HandleHotkeys([]string{"x", "d"})
+	assert.Nil(err, name)
+	_, err = o.HandleHotkey("Shift+d")
+	assert.Nil(err, name)
+	_, err = o.DropConfigWait()
+	assert.Nil(err, name)
+
+	assert.FileExists(filepath.Join(dir2, "a.txt"), name)
+	for _, n := range []string{"a.txt", "b.txt"} {
+		_, err = os.Stat(filepath.Join(dir1, n))
+		assert.True(os.IsNotExist(err), name)
+		assert.FileExists(filepath.Join(trash, trashFilesDir, n), name)
+		assert.FileExists(filepath.Join(trash, trashInfoDir, n+trashInfoExt), name)
+	}
+}
+
+func TestOrganizerInteractionLinks(t *testing.T) {
+	assert := assert.New(t)
+	name := "TestOrganizerInteractionLinks"
+
+	dir1, err := ioutil.TempDir("", "dir")
+	assert.Nil(err)
+	defer os.RemoveAll(dir1)
+	for _, n := range []string{"a.txt", "b.txt"} {
+		err = ioutil.WriteFile(filepath.Join(dir1, n), []byte("123"), 0644)
+		assert.Nil(err)
+	}
+
+	dir2, err := ioutil.TempDir("", "dir")
+	assert.Nil(err)
+	defer os.RemoveAll(dir2)
+
+	dir3, err := ioutil.TempDir("", "dir")
+	assert.Nil(err)
+	defer os.RemoveAll(dir3)
+
+	config := configWithSrcDirAndDstDirMove(dir1, dir2)
+	config.Src.DefaultOpType = OpTypeSymlink
+	config.Dst.Dirs = append(config.Dst.Dirs, &DstDir{Hotkey: "h", Dir: dir3, OpType: OpTypeHardlink})
+
+	o := NewOrganizer()
+	_, err = o.LoadConfig(config)
+	assert.Nil(err, name)
+
+	// Link a.txt and remove the link
+	_, err = o.HandleHotkey("x")
+	assert.Nil(err, name)
+	_, err = o.Undo()
+	assert.Nil(err, name)
+	_, err = os.Lstat(filepath.Join(dir2, "a.txt"))
+	assert.True(os.IsNotExist(err), name)
+
+	// Symlink a.txt, hardlink b.txt
+	_, err = o.HandleHotkey("x")
+	assert.Nil(err, name)
+	_, err = o.HandleHotkey("h")
+	assert.Nil(err, name)
+	_, err = o.DropConfigWait()
+	assert.Nil(err, name)
+
+	for _, n := range []string{"a.txt", "b.txt"} {
+		assert.FileExists(filepath.Join(dir1, n), name)
+	}
+	target, err := os.Readlink(filepath.Join(dir2, "a.txt"))
+	assert.Nil(err, name)
+	assert.Equal(filepath.Join(dir1, "a.txt"), target, name)
+	srcInfo, err := os.Stat(filepath.Join(dir1, "b.txt"))
+	assert.Nil(err, name)
+	dstInfo, err := os.Lstat(filepath.Join(dir3, "b.txt"))
+	assert.Nil(err, name)
+	assert.True(os.SameFile(srcInfo, dstInfo), name)
+}
+
+func TestOrganizerInteractionMultipleLinkDstDirs(t *testing.T) {
+	assert := assert.New(t)
+	name := "TestOrganizerInteractionMultipleLinkDstDirs"
+
+	dir1, err := ioutil.TempDir("", "dir")
+	assert.Nil(err)
+	defer os.RemoveAll(dir1)
+	for _, n := range []string{"a.txt", "b.txt"} {
+		err = ioutil.WriteFile(filepath.Join(dir1, n), []byte("123"), 0644)
+		assert.Nil(err)
+	}
+
+	dir2, err := ioutil.TempDir("", "dir")
+	assert.Nil(err)
+	defer os.RemoveAll(dir2)
+
+	dir3, err := ioutil.TempDir("", "dir")
+	assert.Nil(err)
+	defer os.RemoveAll(dir3)
+
+	dir4, err := ioutil.TempDir("", "dir")
+	assert.Nil(err)
+	defer os.RemoveAll(dir4)
+
+	config := configWithSrcDirAndDstDirMove(dir1, dir2)
+	config.Src.DefaultOpType = OpTypeSymlink
+	config.Dst.Dirs = append(config.Dst.Dirs,
+		&DstDir{Hotkey: "y", Dir: dir3},
+		&DstDir{Hotkey: "h", Dir: dir4, OpType: OpTypeHardlink},
+	)
+
+	o := NewOrganizer()
+	_, err = o.LoadConfig(config)
+	assert.Nil(err, name)
+
+	// Symlink a.txt twice, symlink and hardlink b.txt
+	_, err = o.HandleHotkeys([]string{"x", "y"})
+	assert.Nil(err, name)
+	_, err = o.HandleHotkeys([]string{"h", "x"})
+	assert.Nil(err, name)
+	_, err = o.DropConfigWait()
+	assert.Nil(err, name)
+
+	for _, p := range []string{
+		filepath.Join(dir2, "a.txt"),
+		filepath.Join(dir3, "a.txt"),
+		filepath.Join(dir2, "b.txt"),
+	} {
+		target, err := os.Readlink(p)
+		assert.Nil(err, name)
+		assert.Equal(filepath.Join(dir1, filepath.Base(p)), target, name)
+	}
+	srcInfo, err := os.Stat(filepath.Join(dir1, "b.txt"))
+	assert.Nil(err, name)
+	dstInfo, err := os.Lstat(filepath.Join(dir4, "b.txt"))
+	assert.Nil(err, name)
+	assert.True(os.SameFile(srcInfo, dstInfo), name)
+}
+
+func TestOrganizerInteractionRenameTemplate(t *testing.T) {
+	assert := assert.New(t)
+	name := "TestOrganizerInteractionRenameTemplate"
+
+	dir1, err := ioutil.TempDir("", "dir")
+	assert.Nil(err)
+	defer os.RemoveAll(dir1)
+	mtime := time.Date(2024, 3, 15, 10, 0, 0, 0, time.Local)
+	for _, n := range []string{"a.pdf", "b.pdf", "c.pdf"} {
+		p := filepath.Join(dir1, n)
+		err = ioutil.WriteFile(p, []byte(n), 0644)
+		assert.Nil(err)
+		err = os.Chtimes(p, mtime, mtime)
+		assert.Nil(err)
+	}
+
+	dir2, err := ioutil.TempDir("", "dir")
+	assert.Nil(err)
+	defer os.RemoveAll(dir2)
+
+	config := configWithSrcDirAndDstDirMove(dir1, dir2)
+	config.Dst.Dirs[0].Rename = "{mtime:2006-01-02}_invoice_{counter:3}.{ext}"
+
+	o := NewOrganizer()
+	_, err = o.LoadConfig(config)
+	assert.Nil(err, name)
+
+	// The counter value is released by undo
+	_, err = o.HandleHotkey("x")
+	assert.Nil(err, name)
+	_, err = o.Undo()
+	assert.Nil(err, name)
+	for i := 0; i < 3; i++ {
+		_, err = o.HandleHotkey("x")
+		assert.Nil(err, name)
+	}
+	_, err = o.DropConfigWait()
+	assert.Nil(err, name)
+
+	for i, n := range []string{"2024-03-15_invoice_001.pdf", "2024-03-15_invoice_002.pdf", "2024-03-15_invoice_003.pdf"} {
+		content, err := ioutil.ReadFile(filepath.Join(dir2, n))
+		assert.Nil(err, name)
+		assert.Equal([]string{"a.pdf", "b.pdf", "c.pdf"}[i], string(content), name)
+	}
+}
+
+func TestOrganizerInteractionRenameTemplateSameDstDir(t *testing.T) {
+	assert := assert.New(t)
+	name := "TestOrganizerInteractionRenameTemplateSameDstDir"
+
+	dir1, err := ioutil.TempDir("", "dir")
+	assert.Nil(err)
+	defer os.RemoveAll(dir1)
+	err = ioutil.WriteFile(filepath.Join(dir1, "a.txt"), []byte("123"), 0644)
+	assert.Nil(err)
+
+	dir2, err := ioutil.TempDir("", "dir")
+	assert.Nil(err)
+	defer os.RemoveAll(dir2)
+
+	config := configWithSrcDirAndDstDir(dir1, dir2)
+	config.Dst.Dirs[0].Rename = "{name}_{counter:3}.{ext}"
+
+	o := NewOrganizer()
+	_, err = o.LoadConfig(config)
+	assert.Nil(err, name)
+
+	// Same destination twice, even with different rendered names, ignored
+	for _, hotkeys := range [][]string{{"x", "x"}, {"x", "Shift+x"}} {
+		status, err := o.HandleHotkeys(hotkeys)
+		assert.Nil(err, name)
+		assert.Equal(0, status.CurrentFileIndex, name)
+	}
+	_, err = o.HandleHotkey("x")
+	assert.Nil(err, name)
+	_, err = o.DropConfigWait()
+	assert.Nil(err, name)
+
+	names, err := ioutil.ReadDir(dir2)
+	assert.Nil(err, name)
+	assert.Len(names, 1, name)
+	assert.FileExists(filepath.Join(dir2, "a_001.txt"), name)
+}
+
+func TestOrganizerInteractionRenameTemplateReplacedDecision(t *testing.T) {
+	assert := assert.New(t)
+	name := "TestOrganizerInteractionRenameTemplateReplacedDecision"
+
+	dir1, err := ioutil.TempDir("", "dir")
+	assert.Nil(err)
+	defer os.RemoveAll(dir1)
+	for _, n := range []string{"a.pdf", "b.pdf", "c.pdf"} {
+		err = ioutil.WriteFile(filepath.Join(dir1, n), []byte(n), 0644)
+		assert.Nil(err)
+	}
+
+	dir2, err := ioutil.TempDir("", "dir")
+	assert.Nil(err)
+	defer os.RemoveAll(dir2)
+
+	config := configWithSrcDirAndDstDirMove(dir1, dir2)
+	config.Dst.Dirs[0].Rename = "invoice_{counter:3}.{ext}"
+	config.Ops.Deferred = true
+
+	o := NewOrganizer()
+	_, err = o.LoadConfig(config)
+	assert.Nil(err, name)
+
+	// Stage a and b, then skip a and send b again:
+	// the values released by the replaced decisions are reused
+	_, err = o.HandleHotkey("x")
+	assert.Nil(err, name)
+	_, err = o.HandleHotkey("x")
+	assert.Nil(err, name)
+	_, err = o.GoToFile(0)
+	assert.Nil(err, name)
+	_, err = o.HandleHotkey(" ")
+	assert.Nil(err, name)
+	status, err := o.HandleHotkey("x")
+	assert.Nil(err, name)
+	assert.Equal("c.pdf", status.CurrentFile.Name, name)
+	_, err = o.HandleHotkey("x")
+	assert.Nil(err, name)
+
+	// Failed decisions release the values taken
+	_, err = o.GoToFile(0)
+	assert.Nil(err, name)
+	_, err = o.HandleHotkeys([]string{"x", "y"})
+	assert.Nil(err, name)
+	_, err = o.HandleHotkey("x")
+	assert.Nil(err, name)
+
+	_, err = o.CommitDecisions()
+	assert.Nil(err, name)
+	_, err = o.DropConfigWait()
+	assert.Nil(err, name)
+
+	for i, n := range []string{"invoice_001.pdf", "invoice_002.pdf", "invoice_003.pdf"} {
+		content, err := ioutil.ReadFile(filepath.Join(dir2, n))
+		assert.Nil(err, name)
+		assert.Equal([]string{"b.pdf", "c.pdf", "a.pdf"}[i], string(content), name)
+	}
+	_, err = os.Stat(filepath.Join(dir2, "invoice_004.pdf"))
+	assert.True(os.IsNotExist(err), name)
+}
+
+func TestOrganizerInteractionSubpathTemplate(t *testing.T) {
+	assert := assert.New(t)
+	name := "TestOrganizerInteractionSubpathTemplate"
+
+	dir1, err := ioutil.TempDir("", "dir")
+	assert.Nil(err)
+	defer os.RemoveAll(dir1)
+	mtime := time.Date(2024, 3, 15, 10, 0, 0, 0, time.Local)
+	for _, n := range []string{"a.pdf", "b.jpg"} {
+		p := filepath.Join(dir1, n)
+		err = ioutil.WriteFile(p, []byte(n), 0644)
+		assert.Nil(err)
+		err = os.Chtimes(p, mtime, mtime)
+		assert.Nil(err)
+	}
+
+	dir2, err := ioutil.TempDir("", "dir")
+	assert.Nil(err)
+	defer os.RemoveAll(dir2)
+
+	config := configWithSrcDirAndDstDirMove(dir1, dir2)
+	config.Dst.Dirs[0].Subpath = "{mtime:2006}/{mtime:01}/{ext}"
+
+	o := NewOrganizer()
+	_, err = o.LoadConfig(config)
+	assert.Nil(err, name)
+	for i := 0; i < 2; i++ {
+		_, err = o.HandleHotkey("x")
+		assert.Nil(err, name)
+	}
+	_, err = o.DropConfigWait()
+	assert.Nil(err, name)
+
+	assert.FileExists(filepath.Join(dir2, "2024", "03", "pdf", "a.pdf"), name)
+	assert.FileExists(filepath.Join(dir2, "2024", "03", "jpg", "b.jpg"), name)
+}
+
+func TestOrganizerInteractionRenameCurrentFile(t *testing.T) {
+	assert := assert.New(t)
+	name := "TestOrganizerInteractionRenameCurrentFile"
+
+	dir1, err := ioutil.TempDir("", "dir")
+	assert.Nil(err)
+	defer os.RemoveAll(dir1)
+	for _, n := range []string{"a.txt", "b.txt"} {
+		err = ioutil.WriteFile(filepath.Join(dir1, n), []byte(n), 0644)
+		assert.Nil(err)
+	}
+
+	dir2, err := ioutil.TempDir("", "dir")
+	assert.Nil(err)
+	defer os.RemoveAll(dir2)
+
+	o := NewOrganizer()
+	_, err = o.LoadConfig(configWithSrcDirAndDstDirMove(dir1, dir2))
+	assert.Nil(err, name)
+
+	for _, n := range []string{"", "..", "sub/c.txt", "b.txt"} {
+		_, err = o.RenameCurrentFile(n)
+		assert.NotNil(err, n)
+	}
+
+	status, err := o.RenameCurrentFile("invoice.pdf")
+	assert.Nil(err, name)
+	file := status.CurrentFile
+	assert.Equal("invoice.pdf", file.Name, name)
+	assert.Equal(filepath.Join(dir1, "invoice.pdf"), file.Path, name)
+	assert.Equal(".pdf", file.Ext, name)
+	assert.Equal(fileURL(0, dir1, file.Path), file.URL, name)
+	assert.FileExists(file.Path, name)
+	_, err = os.Stat(filepath.Join(dir1, "a.txt"))
+	assert.True(os.IsNotExist(err), name)
+
+	// Files with operations cannot be renamed
+	_, err = o.HandleHotkey("x")
+	assert.Nil(err, name)
+	_, err = o.PreviousFile()
+	assert.Nil(err, name)
+	_, err = o.RenameCurrentFile("other.pdf")
+	assert.NotNil(err, name)
+
+	_, err = o.DropConfigWait()
+	assert.Nil(err, name)
+	assert.FileExists(filepath.Join(dir2, "invoice.pdf"), name)
+}
+
+func TestOrganizerInteractionCheckStable(t *testing.T) {
+	assert := assert.New(t)
+	name := "TestOrganizerInteractionCheckStable"
+
+	dir1, err := ioutil.TempDir("", "dir")
+	assert.Nil(err)
+	defer os.RemoveAll(dir1)
+	for _, n := range []string{"a.txt", "b.txt.crdownload"} {
+		err = ioutil.WriteFile(filepath.Join(dir1, n), []byte("123"), 0644)
+		assert.Nil(err)
+	}
+
+	dir2, err := ioutil.TempDir("", "dir")
+	assert.Nil(err)
+	defer os.RemoveAll(dir2)
+
+	config := configWithSrcDirAndDstDirMove(dir1, dir2)
+	config.Src.CheckStable = true
+	config.Src.StableSeconds = 60
+
+	o := NewOrganizer()
+	status, err := o.LoadConfig(config)
+	assert.Nil(err, name)
+	assert.NotEmpty(status.CurrentFileNotReady, name)
+
+	// Files still being written are not sent
+	status, err = o.HandleHotkey("x")
+	assert.Nil(err, name)
+	assert.Equal(0, status.CurrentFileIndex, name)
+	assert.Empty(status.CurrentFileOperations, name)
+
+	// Checking the status does not record changes
+	err = ioutil.WriteFile(filepath.Join(dir1, "a.txt"), []byte("12345"), 0644)
+	assert.Nil(err)
+	o.mutex.Lock()
+	o.organizer.files[0].unchangedSince = time.Now().Add(-time.Hour)
+	o.mutex.Unlock()
+	status, err = o.OrganizerStatus()
+	assert.Nil(err, name)
+	assert.NotEmpty(status.CurrentFileNotReady, name)
+	assert.Equal(int64(3), status.CurrentFile.Size, name)
+
+	// Files changed since last observed are not sent
+	status, err = o.HandleHotkey("x")
+	assert.Nil(err, name)
+	assert.Equal(0, status.CurrentFileIndex, name)
+	assert.Equal(int64(5), status.CurrentFile.Size, name)
+
+	// Files unchanged for long enough are sent
+	o.mutex.Lock()
+	o.organizer.files[0].unchangedSince = time.Now().Add(-time.Hour)
+	o.mutex.Unlock()
+	status, err = o.OrganizerStatus()
+	assert.Nil(err, name)
+	assert.Empty(status.CurrentFileNotReady, name)
+	status, err = o.HandleHotkey("x")
+	assert.Nil(err, name)
+	assert.Equal(1, status.CurrentFileIndex, name)
+
+	// Partial downloads are never sent, but can be skipped
+	o.mutex.Lock()
+	o.organizer.files[1].unchangedSince = time.Now().Add(-time.Hour)
+	o.mutex.Unlock()
+	status, err = o.HandleHotkey("x")
+	assert.Nil(err, name)
+	assert.Equal(1, status.CurrentFileIndex, name)
+	assert.Contains(status.CurrentFileNotReady, "partial download", name)
+	status, err = o.HandleHotkey(" ")
+	assert.Nil(err, name)
+	assert.Equal(2, status.CurrentFileIndex, name)
+
+	_, err = o.DropConfigWait()
+	assert.Nil(err, name)
+	assert.FileExists(filepath.Join(dir2, "a.txt"), name)
+	assert.FileExists(filepath.Join(dir1, "b.txt.crdownload"), name)
+}
+
+func TestOrganizerInteractionWatchSrcDir(t *testing.T) {
+	assert := assert.New(t)
+	name := "TestOrganizerInteractionWatchSrcDir"
+
+	dir1, err := ioutil.TempDir("", "dir")
+	assert.Nil(err)
+	defer os.RemoveAll(dir1)
+	for _, n := range []string{"a.txt", "b.txt"} {
+		err = ioutil.WriteFile(filepath.Join(dir1, n), []byte("123"), 0644)
+		assert.Nil(err)
+	}
+
+	dir2, err := ioutil.TempDir("", "dir")
+	assert.Nil(err)
+	defer os.RemoveAll(dir2)
+
+	config := configWithSrcDirAndDstDirMove(dir1, dir2)
+	config.Src.IncludeSubdirs = true
+
+	o := NewOrganizer()
+	_, err = o.LoadConfig(config)
+	assert.Nil(err, name)
+
+	waitStatus := func(done func(*OrganizerStatus) bool) *OrganizerStatus {
+		var status *OrganizerStatus
+		for i := 0; i < 50; i++ {
+			status, err = o.OrganizerStatus()
+			assert.Nil(err, name)
+			if done(status) {
+				break
+			}
+			time.Sleep(100 * time.Millisecond)
+		}
+		return status
+	}
+
+	// New files, also in new subdirectories, are appended
+	err = os.Mkdir(filepath.Join(dir1, "sub"), 0755)
+	assert.Nil(err)
+	time.Sleep(100 * time.Millisecond)
+	err = ioutil.WriteFile(filepath.Join(dir1, "sub", "c.txt"), []byte("123"), 0644)
+	assert.Nil(err)
+	status := waitStatus(func(s *OrganizerStatus) bool { return s.NumFiles == 3 })
+	assert.Equal(3, status.NumFiles, name)
+	assert.Equal(1, status.NumAddedFiles, name)
+	assert.Equal("c.txt", o.organizer.files[2].Name, name)
+
+	// Vanished files are gone and cannot be sent
+	err = os.Remove(filepath.Join(dir1, "a.txt"))
+	assert.Nil(err)
+	status = waitStatus(func(s *OrganizerStatus) bool { return s.NumGoneFiles == 1 })
+	assert.Equal(1, status.NumGoneFiles, name)
+	assert.True(status.CurrentFile.Gone, name)
+	assert.NotEmpty(status.CurrentFileNotReady, name)
+	status, err = o.HandleHotkey("x")
+	assert.Nil(err, name)
+	assert.Equal(0, status.CurrentFileIndex, name)
+
+	// Files moved by the organizer are not gone
+	status, err = o.HandleHotkey(" ")
+	assert.Nil(err, name)
+	status, err = o.HandleHotkey("x")
+	assert.Nil(err, name)
+	assert.Equal(2, status.CurrentFileIndex, name)
+	time.Sleep(time.Second)
+	status, err = o.OrganizerStatus()
+	assert.Nil(err, name)
+	assert.Equal(1, status.NumGoneFiles, name)
+	o.mutex.Lock()
+	assert.Equal(map[int]bool{1: true}, o.organizer.submittedFileIndexes(), name)
+	o.mutex.Unlock()
+
+	// Gone files can reappear
+	err = ioutil.WriteFile(filepath.Join(dir1, "a.txt"), []byte("123"), 0644)
+	assert.Nil(err)
+	status = waitStatus(func(s *OrganizerStatus) bool { return s.NumGoneFiles == 0 })
+	assert.Equal(0, status.NumGoneFiles, name)
+	assert.Equal(3, status.NumFiles, name)
+
+	_, err = o.DropConfigWait()
+	assert.Nil(err, name)
+	assert.FileExists(filepath.Join(dir2, "b.txt"), name)
+}
+
+func organizerWithConfig(config *Config) *Organizer {
+	o := NewOrganizer()
+	_, _ = o.LoadConfig(config)
+	return o
+}
+
+func TestOrganizerInteractionMultipleSrcDirs(t *testing.T) {
+	assert := assert.New(t)
+	name := "TestOrganizerInteractionMultipleSrcDirs"
+
+	dir1, err := ioutil.TempDir("", "dir")
+	assert.Nil(err)
+	defer os.RemoveAll(dir1)
+	err = ioutil.WriteFile(filepath.Join(dir1, "a.txt"), []byte("a"), 0644)
+	assert.Nil(err)
+
+	dir2, err := ioutil.TempDir("", "dir")
+	assert.Nil(err)
+	defer os.RemoveAll(dir2)
+	err = os.Mkdir(filepath.Join(dir2, "sub"), 0755)
+	assert.Nil(err)
+	err = ioutil.WriteFile(filepath.Join(dir2, "sub", "b.txt"), []byte("b"), 0644)
+	assert.Nil(err)
+
+	dir3, err := ioutil.TempDir("", "dir")
+	assert.Nil(err)
+	defer os.RemoveAll(dir3)
+
+	config := configWithSrcDirAndDstDir(dir1, dir3)
+	config.Src.ExtraDirs = []*SrcDir{
+		{Dir: dir2, IncludeSubdirs: true, DefaultOpType: OpTypeMove},
+	}
+
+	o := NewOrganizer()
+	status, err := o.LoadConfig(config)
+	assert.Nil(err, name)
+	assert.Equal(2, status.NumFiles, name)
+
+	// Files of each source are served under the source ID
+	files := o.organizer.files
+	assert.Equal(0, files[0].SrcID, name)
+	assert.Equal(defaultFileServerAddr+"/0/a.txt", files[0].URL, name)
+	assert.Equal(1, files[1].SrcID, name)
+	assert.Equal(defaultFileServerAddr+"/1/sub/b.txt", files[1].URL, name)
+
+	// Files are copied or moved with the default operation type of their source
+	for i := 0; i < 2; i++ {
+		_, err = o.HandleHotkey("x")
+		assert.Nil(err, name)
+	}
+	_, err = o.DropConfigWait()
+	assert.Nil(err, name)
+
+	assert.FileExists(filepath.Join(dir1, "a.txt"), name)
+	assert.FileExists(filepath.Join(dir3, "a.txt"), name)
+	_, err = os.Stat(filepath.Join(dir2, "sub", "b.txt"))
+	assert.True(os.IsNotExist(err), name)
+	assert.FileExists(filepath.Join(dir3, "b.txt"), name)
 }
 
 func emptyConfig() *Config {
@@ -0,0 +1,250 @@
+package core
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Plan CSV column names.
+const (
+	planCSVColumnSrc = "src"
+	planCSVColumnDst = "dst"
+	planCSVColumnOp  = "op"
+)
+
+// Plan represents the list of operations that the organizer would execute.
+type Plan struct {
+	Steps []*PlanStep `json:"steps"`
+}
+
+// PlanStep represents an operation in a plan.
+// PredictedDstPath is the destination expected once name collisions
+// with existing files or with previous steps are resolved,
+// in which case Collision is true.
+// Outcome is the expected outcome of the operation according to its collision policy;
+// steps leaving the file untouched have an empty PredictedDstPath.
+type PlanStep struct {
+	OpID             int64     `json:"opId"`
+	Op               OpType    `json:"op"`
+	SrcPath          string    `json:"srcPath"`
+	DstPath          string    `json:"dstPath"`
+	PredictedDstPath string    `json:"predictedDstPath"`
+	Collision        bool      `json:"collision"`
+	Outcome          OpOutcome `json:"outcome"`
+	Err              string    `json:"err"`
+}
+
+// newPlan returns the plan for the given operations, in order.
+func newPlan(ops []*Operation) *Plan {
+	plan := &Plan{
+		Steps: make([]*PlanStep, len(ops)),
+	}
+	// reserved maps the destinations of previous steps
+	// to the source of the file expected there.
+	reserved := make(map[string]string)
+	for i, op := range ops {
+		step := &PlanStep{
+			OpID:    op.ID,
+			Op:      op.Op,
+			SrcPath: op.SrcPath,
+			DstPath: op.DstPath,
+		}
+		if err := predictStep(step, op, reserved); err != nil {
+			step.Err = err.Error()
+		} else if step.PredictedDstPath != "" {
+			reserved[step.PredictedDstPath] = op.SrcPath
+		}
+		plan.Steps[i] = step
+	}
+	return plan
+}
+
+// predictStep predicts the destination and the outcome of the given operation
+// according to its collision policy.
+func predictStep(step *PlanStep, op *Operation, reserved map[string]string) error {
+	dstPath := filepath.Clean(op.DstPath)
+	existingPath, collision := reserved[dstPath]
+	if !collision {
+		if _, err := os.Lstat(dstPath); err == nil {
+			existingPath, collision = dstPath, true
+		}
+	}
+
+	// Trashed files are never overwritten, whatever the collision policy.
+	policy := op.CollisionPolicy
+	if op.Op == OpTypeTrash {
+		policy = CollisionPolicyRename
+	}
+
+	outcome := OpOutcomeCreated
+	if collision {
+		var err error
+		if outcome, err = resolveCollision(policy, op.SrcPath, existingPath); err != nil {
+			return err
+		}
+	}
+
+	var predictedDstPath string
+	switch outcome {
+	case OpOutcomeSkipped, OpOutcomeIdentical:
+	case OpOutcomeOverwritten:
+		predictedDstPath = dstPath
+	default:
+		var renamed bool
+		var err error
+		predictedDstPath, renamed, err = predictDstPath(dstPath, op.MaxTries, reserved)
+		if err != nil {
+			return err
+		}
+		if renamed {
+			outcome = OpOutcomeRenamed
+		}
+	}
+
+	step.PredictedDstPath = predictedDstPath
+	step.Collision = collision
+	step.Outcome = outcome
+	return nil
+}
+
+// Script returns a POSIX shell script executing the plan.
+// Steps without an available destination or leaving the file untouched
+// are reported as comments.
+// Missing destination subdirectories are created with mkdir -p.
+func (p *Plan) Script() string {
+	var b strings.Builder
+	b.WriteString("#!/bin/sh\n")
+	b.WriteString("set -e\n")
+	madeDirs := make(map[string]bool)
+	for _, step := range p.Steps {
+		if step.Err != "" {
+			fmt.Fprintf(&b, "# skipped %s: %s\n", step.SrcPath, strings.ReplaceAll(step.Err, "\n", " "))
+			continue
+		}
+		if step.Outcome.isSkipped() {
+			fmt.Fprintf(&b, "# skipped %s: destination %s\n", step.SrcPath, step.Outcome)
+			continue
+		}
+		cmd, ok := scriptCommands[step.Op]
+		if !ok {
+			fmt.Fprintf(&b, "# skipped %s: operation type %q not supported\n", step.SrcPath, step.Op)
+			continue
+		}
+		dstDir := filepath.Dir(step.PredictedDstPath)
+		if _, err := os.Stat(dstDir); os.IsNotExist(err) && !madeDirs[dstDir] {
+			fmt.Fprintf(&b, "mkdir -p -- %s\n", shellQuote(dstDir))
+			madeDirs[dstDir] = true
+		}
+		fmt.Fprintf(&b, "%s -- %s %s\n", cmd, shellQuote(step.SrcPath), shellQuote(step.PredictedDstPath))
+	}
+	return b.String()
+}
+
+// scriptCommands maps operation types to shell commands.
+var scriptCommands = map[OpType]string{
+	OpTypeCopy:     "cp",
+	OpTypeMove:     "mv",
+	OpTypeSymlink:  "ln -s",
+	OpTypeHardlink: "ln",
+}
+
+// shellQuote quotes the given string for a POSIX shell.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// CSV returns the plan in CSV format, with a src,dst,op header.
+// Steps without an available destination or leaving the file untouched are left out.
+func (p *Plan) CSV() (string, error) {
+	var b bytes.Buffer
+	w := csv.NewWriter(&b)
+	records := [][]string{{planCSVColumnSrc, planCSVColumnDst, planCSVColumnOp}}
+	for _, step := range p.Steps {
+		if step.Err != "" || step.Outcome.isSkipped() {
+			continue
+		}
+		records = append(records, []string{step.SrcPath, step.PredictedDstPath, string(step.Op)})
+	}
+	if err := w.WriteAll(records); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// parsePlanCSV parses a plan in the format returned by Plan.CSV.
+// The header is required, while its columns can be in any order.
+func parsePlanCSV(r io.Reader) ([]*PlanStep, error) {
+	records, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("plan is empty")
+	}
+
+	columns := make(map[string]int)
+	for i, name := range records[0] {
+		columns[strings.TrimSpace(name)] = i
+	}
+	for _, name := range []string{planCSVColumnSrc, planCSVColumnDst, planCSVColumnOp} {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("plan has no %q column", name)
+		}
+	}
+
+	steps := make([]*PlanStep, 0, len(records)-1)
+	for i, record := range records[1:] {
+		line := i + 2
+		step := &PlanStep{
+			Op:      OpType(record[columns[planCSVColumnOp]]),
+			SrcPath: record[columns[planCSVColumnSrc]],
+			DstPath: record[columns[planCSVColumnDst]],
+		}
+		if !step.Op.IsValid() {
+			return nil, fmt.Errorf("line %d: operation type %q not valid", line, step.Op)
+		}
+		if !filepath.IsAbs(step.SrcPath) || !filepath.IsAbs(step.DstPath) {
+			return nil, fmt.Errorf("line %d: paths must be absolute", line)
+		}
+		steps = append(steps, step)
+	}
+	return steps, nil
+}
+
+// predictDstPath returns the first available destination,
+// following the same naming scheme used when executing operations.
+func predictDstPath(dstPath string, maxTries int, reserved map[string]string) (string, bool, error) {
+	dir, name := filepath.Split(filepath.Clean(dstPath))
+	for i := 0; i <= maxTries; i++ {
+		candidate := filepath.Join(dir, insertCounter(name, i))
+		if _, ok := reserved[candidate]; ok {
+			continue
+		}
+		if _, err := os.Lstat(candidate); err == nil {
+			continue
+		}
+		return candidate, i > 0, nil
+	}
+	return "", true, fmt.Errorf("no available destination for %q after %d tries", dstPath, maxTries)
+}
+
+// insertCounter inserts a counter in the given filename with the given value,
+// before the last dot, if any; for example, "test.json" becomes "test(3).json".
+// If the value is less than 1, the original filename is returned.
+func insertCounter(filename string, value int) string {
+	if value < 1 {
+		return filename
+	}
+
+	insertPos := strings.LastIndex(filename, ".")
+	if insertPos == -1 {
+		insertPos = len(filename)
+	}
+
+	return fmt.Sprintf("%s(%d)%s", filename[:insertPos], value, filename[insertPos:])
+}
@@ -0,0 +1,219 @@
+package core
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_insertCounter(t *testing.T) {
+	assert := assert.New(t)
+
+	tests := []struct {
+		name     string
+		filename string
+		value    int
+		want     string
+	}{
+		{"no counter", "test.json", 0, "test.json"},
+		{"counter with extension", "test.json", 3, "test(3).json"},
+		{"counter without extension", "test", 1, "test(1)"},
+		{"counter with multiple dots", "test.tar.gz", 2, "test.tar(2).gz"},
+	}
+	for _, tt := range tests {
+		got := insertCounter(tt.filename, tt.value)
+		assert.Equal(tt.want, got, tt.name)
+	}
+}
+
+func Test_newPlan(t *testing.T) {
+	assert := assert.New(t)
+	name := "Test_newPlan"
+
+	dir, err := ioutil.TempDir("", "dir")
+	assert.Nil(err)
+	defer os.RemoveAll(dir)
+	err = ioutil.WriteFile(filepath.Join(dir, "a.txt"), []byte("123"), 0644)
+	assert.Nil(err)
+
+	ops := []*Operation{
+		{ID: 1, Op: OpTypeCopy, SrcPath: "/src/a.txt", DstPath: filepath.Join(dir, "a.txt"), MaxTries: 10},
+		{ID: 2, Op: OpTypeMove, SrcPath: "/src/b.txt", DstPath: filepath.Join(dir, "b.txt"), MaxTries: 10},
+		{ID: 3, Op: OpTypeMove, SrcPath: "/src/sub/b.txt", DstPath: filepath.Join(dir, "b.txt"), MaxTries: 10},
+		{ID: 4, Op: OpTypeMove, SrcPath: "/src/sub/a.txt", DstPath: filepath.Join(dir, "a.txt"), MaxTries: 0},
+	}
+	plan := newPlan(ops)
+	assert.Equal(&Plan{
+		Steps: []*PlanStep{
+			{
+				OpID:             1,
+				Op:               OpTypeCopy,
+				SrcPath:          "/src/a.txt",
+				DstPath:          filepath.Join(dir, "a.txt"),
+				PredictedDstPath: filepath.Join(dir, "a(1).txt"),
+				Collision:        true,
+				Outcome:          OpOutcomeRenamed,
+			},
+			{
+				OpID:             2,
+				Op:               OpTypeMove,
+				SrcPath:          "/src/b.txt",
+				DstPath:          filepath.Join(dir, "b.txt"),
+				PredictedDstPath: filepath.Join(dir, "b.txt"),
+				Collision:        false,
+				Outcome:          OpOutcomeCreated,
+			},
+			{
+				OpID:             3,
+				Op:               OpTypeMove,
+				SrcPath:          "/src/sub/b.txt",
+				DstPath:          filepath.Join(dir, "b.txt"),
+				PredictedDstPath: filepath.Join(dir, "b(1).txt"),
+				Collision:        true,
+				Outcome:          OpOutcomeRenamed,
+			},
+			{
+				OpID:    4,
+				Op:      OpTypeMove,
+				SrcPath: "/src/sub/a.txt",
+				DstPath: filepath.Join(dir, "a.txt"),
+				Err:     plan.Steps[3].Err,
+			},
+		},
+	}, plan, name)
+	assert.NotEmpty(plan.Steps[3].Err, name)
+}
+
+func Test_newPlanCollisionPolicy(t *testing.T) {
+	assert := assert.New(t)
+
+	srcDir, err := ioutil.TempDir("", "dir")
+	assert.Nil(err)
+	defer os.RemoveAll(srcDir)
+	dstDir, err := ioutil.TempDir("", "dir")
+	assert.Nil(err)
+	defer os.RemoveAll(dstDir)
+
+	srcPath := filepath.Join(srcDir, "a.txt")
+	err = ioutil.WriteFile(srcPath, []byte("123"), 0644)
+	assert.Nil(err)
+	dstPath := filepath.Join(dstDir, "a.txt")
+	err = ioutil.WriteFile(dstPath, []byte("12"), 0644)
+	assert.Nil(err)
+	identicalPath := filepath.Join(dstDir, "b.txt")
+	err = ioutil.WriteFile(identicalPath, []byte("123"), 0644)
+	assert.Nil(err)
+	newPath := filepath.Join(dstDir, "c.txt")
+
+	tests := []struct {
+		name             string
+		policy           CollisionPolicy
+		dstPath          string
+		wantOutcome      OpOutcome
+		wantPredictedDst string
+	}{
+		{"no collision", CollisionPolicySkip, newPath, OpOutcomeCreated, newPath},
+		{"default", "", dstPath, OpOutcomeRenamed, filepath.Join(dstDir, "a(1).txt")},
+		{"rename", CollisionPolicyRename, dstPath, OpOutcomeRenamed, filepath.Join(dstDir, "a(1).txt")},
+		{"skip", CollisionPolicySkip, dstPath, OpOutcomeSkipped, ""},
+		{"overwrite", CollisionPolicyOverwrite, dstPath, OpOutcomeOverwritten, dstPath},
+		{"larger", CollisionPolicyLarger, dstPath, OpOutcomeOverwritten, dstPath},
+		{"not larger", CollisionPolicyLarger, identicalPath, OpOutcomeSkipped, ""},
+		{"identical", CollisionPolicyIdentical, identicalPath, OpOutcomeIdentical, ""},
+		{"not identical", CollisionPolicyIdentical, dstPath, OpOutcomeRenamed, filepath.Join(dstDir, "a(1).txt")},
+	}
+	for _, tt := range tests {
+		plan := newPlan([]*Operation{
+			{ID: 1, Op: OpTypeCopy, SrcPath: srcPath, DstPath: tt.dstPath, MaxTries: 1, CollisionPolicy: tt.policy},
+		})
+		step := plan.Steps[0]
+		assert.Empty(step.Err, tt.name)
+		assert.Equal(tt.wantOutcome, step.Outcome, tt.name)
+		assert.Equal(tt.wantPredictedDst, step.PredictedDstPath, tt.name)
+	}
+}
+
+func TestPlan_Script(t *testing.T) {
+	assert := assert.New(t)
+
+	plan := &Plan{
+		Steps: []*PlanStep{
+			{Op: OpTypeMove, SrcPath: "/src/a b.txt", PredictedDstPath: "/dst/a b.txt"},
+			{Op: OpTypeCopy, SrcPath: "/src/it's.txt", PredictedDstPath: "/dst/it's(1).txt"},
+			{Op: OpTypeCopy, SrcPath: "/src/b.pdf", PredictedDstPath: "/dst/2024/b.pdf"},
+			{Op: OpTypeMove, SrcPath: "/src/c.txt", Err: "no available destination"},
+			{Op: OpTypeMove, SrcPath: "/src/d.txt", Outcome: OpOutcomeIdentical},
+		},
+	}
+	want := `#!/bin/sh
+set -e
+mkdir -p -- '/dst'
+mv -- '/src/a b.txt' '/dst/a b.txt'
+cp -- '/src/it'\''s.txt' '/dst/it'\''s(1).txt'
+mkdir -p -- '/dst/2024'
+cp -- '/src/b.pdf' '/dst/2024/b.pdf'
+# skipped /src/c.txt: no available destination
+# skipped /src/d.txt: destination identical
+`
+	assert.Equal(want, plan.Script())
+}
+
+func TestPlan_CSV(t *testing.T) {
+	assert := assert.New(t)
+	name := "TestPlan_CSV"
+
+	plan := &Plan{
+		Steps: []*PlanStep{
+			{Op: OpTypeMove, SrcPath: "/src/a,b.txt", PredictedDstPath: "/dst/a,b.txt"},
+			{Op: OpTypeCopy, SrcPath: `/src/"c".txt`, PredictedDstPath: `/dst/"c"(1).txt`},
+			{Op: OpTypeMove, SrcPath: "/src/d.txt", Err: "no available destination"},
+		},
+	}
+	got, err := plan.CSV()
+	assert.Nil(err, name)
+	want := `src,dst,op
+"/src/a,b.txt","/dst/a,b.txt",move
+"/src/""c"".txt","/dst/""c""(1).txt",copy
+`
+	assert.Equal(want, got, name)
+
+	steps, err := parsePlanCSV(strings.NewReader(got))
+	assert.Nil(err, name)
+	assert.Equal([]*PlanStep{
+		{Op: OpTypeMove, SrcPath: "/src/a,b.txt", DstPath: "/dst/a,b.txt"},
+		{Op: OpTypeCopy, SrcPath: `/src/"c".txt`, DstPath: `/dst/"c"(1).txt`},
+	}, steps, name)
+}
+
+func Test_parsePlanCSV(t *testing.T) {
+	assert := assert.New(t)
+
+	tests := []struct {
+		name    string
+		csv     string
+		want    []*PlanStep
+		wantErr bool
+	}{
+		{"empty plan", "", nil, true},
+		{"missing column", "src,dst\n/a,/b\n", nil, true},
+		{"invalid op", "src,dst,op\n/a,/b,delete\n", nil, true},
+		{"relative path", "src,dst,op\na,/b,move\n", nil, true},
+		{"wrong number of fields", "src,dst,op\n/a,/b\n", nil, true},
+		{"header only", "src,dst,op\n", []*PlanStep{}, false},
+		{
+			"columns in any order",
+			"op,dst,src\ncopy,/b,/a\n",
+			[]*PlanStep{{Op: OpTypeCopy, SrcPath: "/a", DstPath: "/b"}},
+			false,
+		},
+	}
+	for _, tt := range tests {
+		got, err := parsePlanCSV(strings.NewReader(tt.csv))
+		assert.Equal(tt.wantErr, err != nil, tt.name)
+		assert.Equal(tt.want, got, tt.name)
+	}
+}
@@ -0,0 +1,228 @@
+package core
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+const defaultSessionFilename = "tecla-latest-session.json"
+
+// Session represents the persisted state of an organizing session.
+// Counters maps destination directories' hotkeys to the values
+// of their rename templates' counters, and FreeCounters to the values
+// released below them, to be reused.
+// SortSeed is the seed of the random order of files used in the session.
+type Session struct {
+	Config          *Config            `json:"config"`
+	Decisions       []*SessionDecision `json:"decisions"`
+	CurrentFilePath string             `json:"currentFilePath"`
+	Counters        map[string]int     `json:"counters"`
+	FreeCounters    map[string][]int   `json:"freeCounters"`
+	SortSeed        int64              `json:"sortSeed"`
+}
+
+// SessionDecision represents a decision taken on a file during a session.
+type SessionDecision struct {
+	FilePath string       `json:"filePath"`
+	Skipped  bool         `json:"skipped"`
+	Ops      []*Operation `json:"ops"`
+}
+
+func defaultSessionPath() string {
+	dir, err := teclaConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, defaultSessionFilename)
+}
+
+func readSession(path string) (*Session, error) {
+	sessionJSON, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	session := &Session{}
+	if err := json.Unmarshal(sessionJSON, session); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+func writeSession(path string, session *Session) error {
+	if path == "" {
+		return nil
+	}
+
+	sessionJSON, err := json.Marshal(session)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	tmpPath := path + ".tmp"
+	if err := ioutil.WriteFile(tmpPath, sessionJSON, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
+
+// sessionState returns the current state of the organizer as a Session.
+func (o *organizer) sessionState() *Session {
+	o.opsMutex.Lock()
+	defer o.opsMutex.Unlock()
+
+	session := &Session{
+		Config:       o.config,
+		Decisions:    make([]*SessionDecision, len(o.decisions)),
+		Counters:     make(map[string]int, len(o.counters)),
+		FreeCounters: make(map[string][]int, len(o.freeCounters)),
+		SortSeed:     o.sortSeed,
+	}
+	for hotkey, counter := range o.counters {
+		session.Counters[hotkey] = counter
+	}
+	for hotkey, free := range o.freeCounters {
+		session.FreeCounters[hotkey] = append([]int(nil), free...)
+	}
+	if f := o.currentFile(); f != nil {
+		session.CurrentFilePath = f.Path
+	}
+	for i, d := range o.decisions {
+		ops := make([]*Operation, len(d.ops))
+		for j, op := range d.ops {
+			decisionOp := *op
+			ops[j] = &decisionOp
+		}
+		session.Decisions[i] = &SessionDecision{
+			FilePath: o.files[d.fileIndex].Path,
+			Skipped:  len(d.ops) == 0,
+			Ops:      ops,
+		}
+	}
+	return session
+}
+
+// reconcileSession reorders the freshly gathered files so that the files
+// decided in the given session and still present in the source directory
+// come first, in decision order, followed by the undecided files.
+// Files already moved away are no longer found and are therefore dropped.
+// The current file is restored if still present, otherwise
+// the session resumes right after the decided files.
+// Staged operations, not yet executed, are restored together with
+// the counters of rename templates, and their decisions can be undone
+// to revisit their files.
+// Operations already submitted in the previous session are restored
+// as records, so that their files are not sent again; they are never
+// reverted and their decisions cannot be undone.
+func (o *organizer) reconcileSession(session *Session) {
+	for hotkey, counter := range session.Counters {
+		o.counters[hotkey] = counter
+	}
+	for hotkey, free := range session.FreeCounters {
+		o.freeCounters[hotkey] = append([]int(nil), free...)
+	}
+
+	filesByPath := make(map[string]*File, len(o.files))
+	for _, f := range o.files {
+		filesByPath[f.Path] = f
+	}
+
+	decided := make(map[string]int)
+	files := make(Files, 0, len(o.files))
+	for _, d := range session.Decisions {
+		f, ok := filesByPath[d.FilePath]
+		if !ok {
+			continue
+		}
+		fileIndex, ok := decided[f.Path]
+		if !ok {
+			fileIndex = len(files)
+			decided[f.Path] = fileIndex
+			files = append(files, f)
+		}
+		o.decisions = append(o.decisions, &decision{
+			fileIndex: fileIndex,
+			ops:       o.restoreOperations(d),
+		})
+	}
+	numDecided := len(files)
+
+	for _, f := range o.files {
+		if _, ok := decided[f.Path]; !ok {
+			files = append(files, f)
+		}
+	}
+
+	for i, f := range files {
+		f.ID = int64(i + 1)
+	}
+	o.files = files
+	for _, d := range o.decisions {
+		for _, op := range d.ops {
+			op.FileID = o.files[d.fileIndex].ID
+		}
+	}
+	o.currentFileIndex = numDecided
+	for i, f := range files {
+		if f.Path == session.CurrentFilePath {
+			o.currentFileIndex = i
+			break
+		}
+	}
+}
+
+func (o *organizer) restoreOperations(d *SessionDecision) []*Operation {
+	var ops, staged []*Operation
+	for _, sessionOp := range d.Ops {
+		if sessionOp.Status == OpStatusCanceled || sessionOp.Status == OpStatusReverted {
+			continue
+		}
+		if sessionOp.Status != OpStatusStaged {
+			ops = append(ops, o.restoreOperationRecord(sessionOp))
+			continue
+		}
+		dstPath, err := o.unreservedDstPath(sessionOp.DstPath, sessionOp.MaxTries, nil)
+		if err != nil {
+			continue
+		}
+		op := &Operation{
+			ID:              o.nextOperationID(),
+			Op:              sessionOp.Op,
+			SrcPath:         sessionOp.SrcPath,
+			DstPath:         dstPath,
+			MaxTries:        sessionOp.MaxTries,
+			CollisionPolicy: sessionOp.CollisionPolicy,
+			Status:          OpStatusStaged,
+			done:            make(chan struct{}),
+		}
+		o.operations = append(o.operations, op)
+		staged = append(staged, op)
+	}
+	orderOperations(staged)
+	return append(ops, staged...)
+}
+
+// restoreOperationRecord returns a record of the given operation,
+// submitted in a previous session.
+// The record is not tracked with the organizer's operations.
+func (o *organizer) restoreOperationRecord(sessionOp *Operation) *Operation {
+	op := &Operation{
+		ID:              o.nextOperationID(),
+		Op:              sessionOp.Op,
+		SrcPath:         sessionOp.SrcPath,
+		DstPath:         sessionOp.DstPath,
+		MaxTries:        sessionOp.MaxTries,
+		CollisionPolicy: sessionOp.CollisionPolicy,
+		Status:          sessionOp.Status,
+		FinalDstPath:    sessionOp.FinalDstPath,
+		Outcome:         sessionOp.Outcome,
+		Err:             sessionOp.Err,
+		done:            make(chan struct{}),
+		restored:        true,
+	}
+	close(op.done)
+	return op
+}
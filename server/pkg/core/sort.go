@@ -0,0 +1,149 @@
+package core
+
+import (
+	"math/rand"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/velut/fsutils-go/fs"
+)
+
+// SrcSort enum type.
+type SrcSort string
+
+// SrcSort enum values.
+// The empty value keeps the files in lexical path order.
+const (
+	// SrcSortName sorts files by name, comparing numbers by value.
+	SrcSortName SrcSort = "name"
+	// SrcSortMtimeAsc sorts files from the least to the most recently modified.
+	SrcSortMtimeAsc SrcSort = "mtimeAsc"
+	// SrcSortMtimeDesc sorts files from the most to the least recently modified.
+	SrcSortMtimeDesc SrcSort = "mtimeDesc"
+	// SrcSortSize sorts files from the smallest to the largest.
+	SrcSortSize SrcSort = "size"
+	// SrcSortExt groups files by extension, sorting them by name in each group.
+	SrcSortExt SrcSort = "ext"
+	// SrcSortDir groups files by directory, sorting them by name in each group.
+	SrcSortDir SrcSort = "dir"
+	// SrcSortRandom shuffles files with the configured seed.
+	SrcSortRandom SrcSort = "random"
+)
+
+// IsValid returns true if the SrcSort value belongs to the enum or is empty.
+func (s SrcSort) IsValid() bool {
+	valid := map[SrcSort]bool{
+		"":               true,
+		SrcSortName:      true,
+		SrcSortMtimeAsc:  true,
+		SrcSortMtimeDesc: true,
+		SrcSortSize:      true,
+		SrcSortExt:       true,
+		SrcSortDir:       true,
+		SrcSortRandom:    true,
+	}
+	return valid[s]
+}
+
+// maxSortSeed is the largest seed generated for the random order,
+// so that seeds are represented exactly by JavaScript numbers.
+const maxSortSeed = 1<<53 - 1
+
+// newSortSeed returns a new seed for the random order, between 1 and maxSortSeed.
+func newSortSeed() int64 {
+	r := rand.New(rand.NewSource(time.Now().UnixNano()))
+	return r.Int63n(maxSortSeed) + 1
+}
+
+// sortFiles sorts the given files, in lexical path order, with the given order.
+// Files that compare equal keep their lexical path order.
+func sortFiles(fileInfos []*fs.FileInfo, order SrcSort, seed int64) {
+	switch order {
+	case SrcSortName:
+		sort.SliceStable(fileInfos, func(i, j int) bool {
+			return naturalLess(fileInfos[i].Name, fileInfos[j].Name)
+		})
+	case SrcSortMtimeAsc, SrcSortMtimeDesc:
+		mtimes := make(map[string]time.Time, len(fileInfos))
+		for _, fi := range fileInfos {
+			if info, err := os.Stat(fi.Path); err == nil {
+				mtimes[fi.Path] = info.ModTime()
+			}
+		}
+		sort.SliceStable(fileInfos, func(i, j int) bool {
+			mtime1, mtime2 := mtimes[fileInfos[i].Path], mtimes[fileInfos[j].Path]
+			if order == SrcSortMtimeDesc {
+				return mtime1.After(mtime2)
+			}
+			return mtime1.Before(mtime2)
+		})
+	case SrcSortSize:
+		sort.SliceStable(fileInfos, func(i, j int) bool {
+			return fileInfos[i].Size < fileInfos[j].Size
+		})
+	case SrcSortExt:
+		sort.SliceStable(fileInfos, func(i, j int) bool {
+			ext1, ext2 := strings.ToLower(fileInfos[i].Ext), strings.ToLower(fileInfos[j].Ext)
+			if ext1 != ext2 {
+				return ext1 < ext2
+			}
+			return naturalLess(fileInfos[i].Name, fileInfos[j].Name)
+		})
+	case SrcSortDir:
+		sort.SliceStable(fileInfos, func(i, j int) bool {
+			dir1, dir2 := fileInfos[i].Dir, fileInfos[j].Dir
+			if dir1 != dir2 {
+				return naturalLess(dir1, dir2)
+			}
+			return naturalLess(fileInfos[i].Name, fileInfos[j].Name)
+		})
+	case SrcSortRandom:
+		r := rand.New(rand.NewSource(seed))
+		r.Shuffle(len(fileInfos), func(i, j int) {
+			fileInfos[i], fileInfos[j] = fileInfos[j], fileInfos[i]
+		})
+	}
+}
+
+// naturalLess returns true if s1 comes before s2 in natural order,
+// ignoring case and comparing runs of digits by their numeric value,
+// so that "img2" comes before "img10".
+func naturalLess(s1, s2 string) bool {
+	r1, r2 := []rune(strings.ToLower(s1)), []rune(strings.ToLower(s2))
+	i, j := 0, 0
+	for i < len(r1) && j < len(r2) {
+		if isDigit(r1[i]) && isDigit(r2[j]) {
+			start1, start2 := i, j
+			for i < len(r1) && isDigit(r1[i]) {
+				i++
+			}
+			for j < len(r2) && isDigit(r2[j]) {
+				j++
+			}
+			num1 := strings.TrimLeft(string(r1[start1:i]), "0")
+			num2 := strings.TrimLeft(string(r2[start2:j]), "0")
+			if len(num1) != len(num2) {
+				return len(num1) < len(num2)
+			}
+			if num1 != num2 {
+				return num1 < num2
+			}
+			continue
+		}
+		if r1[i] != r2[j] {
+			return r1[i] < r2[j]
+		}
+		i++
+		j++
+	}
+	if len(r1)-i != len(r2)-j {
+		return len(r1)-i < len(r2)-j
+	}
+	return s1 < s2
+}
+
+func isDigit(r rune) bool {
+	return '0' <= r && r <= '9'
+}
@@ -0,0 +1,101 @@
+package core
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/velut/fsutils-go/fs"
+)
+
+func Test_naturalLess(t *testing.T) {
+	assert := assert.New(t)
+
+	tests := []struct {
+		s1   string
+		s2   string
+		want bool
+	}{
+		{"img2.png", "img10.png", true},
+		{"img10.png", "img2.png", false},
+		{"IMG1.png", "img2.png", true},
+		{"img02.png", "img2.png", true},
+		{"img2.png", "img02.png", false},
+		{"a", "ab", true},
+		{"ab", "a", false},
+		{"a", "a", false},
+	}
+	for _, tt := range tests {
+		assert.Equal(tt.want, naturalLess(tt.s1, tt.s2), tt.s1+" < "+tt.s2)
+	}
+}
+
+func Test_sortFiles(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "dir")
+	assert.Nil(err)
+	defer os.RemoveAll(dir)
+
+	base := time.Date(2024, 3, 15, 10, 0, 0, 0, time.Local)
+	files := []struct {
+		path  string
+		size  int
+		mtime time.Time
+	}{
+		{"a/img10.jpg", 30, base.Add(2 * time.Hour)},
+		{"a/img2.png", 10, base},
+		{"b/img1.jpg", 20, base.Add(time.Hour)},
+	}
+	for _, f := range files {
+		path := filepath.Join(dir, filepath.FromSlash(f.path))
+		err := os.MkdirAll(filepath.Dir(path), 0755)
+		assert.Nil(err)
+		err = ioutil.WriteFile(path, make([]byte, f.size), 0644)
+		assert.Nil(err)
+		err = os.Chtimes(path, f.mtime, f.mtime)
+		assert.Nil(err)
+	}
+
+	tests := []struct {
+		name  string
+		order SrcSort
+		want  []string
+	}{
+		{"path", "", []string{"a/img10.jpg", "a/img2.png", "b/img1.jpg"}},
+		{"name", SrcSortName, []string{"b/img1.jpg", "a/img2.png", "a/img10.jpg"}},
+		{"mtime ascending", SrcSortMtimeAsc, []string{"a/img2.png", "b/img1.jpg", "a/img10.jpg"}},
+		{"mtime descending", SrcSortMtimeDesc, []string{"a/img10.jpg", "b/img1.jpg", "a/img2.png"}},
+		{"size", SrcSortSize, []string{"a/img2.png", "b/img1.jpg", "a/img10.jpg"}},
+		{"extension", SrcSortExt, []string{"b/img1.jpg", "a/img10.jpg", "a/img2.png"}},
+		{"directory", SrcSortDir, []string{"a/img2.png", "a/img10.jpg", "b/img1.jpg"}},
+	}
+	relPaths := func(fileInfos []*fs.FileInfo) []string {
+		got := []string{}
+		for _, fi := range fileInfos {
+			rel, err := filepath.Rel(dir, fi.Path)
+			assert.Nil(err)
+			got = append(got, filepath.ToSlash(rel))
+		}
+		return got
+	}
+	for _, tt := range tests {
+		fileInfos, err := readSrcDir(dir, true, nil, 0)
+		assert.Nil(err, tt.name)
+		sortFiles(fileInfos, tt.order, 0)
+		assert.Equal(tt.want, relPaths(fileInfos), tt.name)
+	}
+
+	// The same seed gives the same order
+	fileInfos1, err := readSrcDir(dir, true, nil, 0)
+	assert.Nil(err)
+	sortFiles(fileInfos1, SrcSortRandom, 42)
+	fileInfos2, err := readSrcDir(dir, true, nil, 0)
+	assert.Nil(err)
+	sortFiles(fileInfos2, SrcSortRandom, 42)
+	assert.Equal(relPaths(fileInfos1), relPaths(fileInfos2))
+	assert.ElementsMatch(tests[0].want, relPaths(fileInfos1))
+}
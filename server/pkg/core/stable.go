@@ -0,0 +1,80 @@
+package core
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// defaultStableSeconds is the default number of seconds for which
+// a file must not be modified before it can be organized.
+const defaultStableSeconds = 2
+
+// partialDownloadExts lists the extensions of the files
+// still being downloaded by common browsers and download managers.
+var partialDownloadExts = []string{
+	".part",
+	".partial",
+	".crdownload",
+	".download",
+	".opdownload",
+	".!ut",
+	".!qb",
+}
+
+// isPartialDownload returns true if the file with the given name is still being downloaded.
+func isPartialDownload(name string) bool {
+	lower := strings.ToLower(name)
+	for _, ext := range partialDownloadExts {
+		if strings.HasSuffix(lower, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// observeFile records the size and modification time of the given file,
+// restarting the time since which they are unchanged if they differ
+// from the ones recorded the last time the file was seen.
+func observeFile(file *File) error {
+	info, err := os.Stat(file.Path)
+	if err != nil {
+		return err
+	}
+	changed := info.Size() != file.Size || !info.ModTime().Equal(file.modTime)
+	if changed || file.unchangedSince.IsZero() {
+		file.Size = info.Size()
+		file.modTime = info.ModTime()
+		file.unchangedSince = time.Now()
+	}
+	return nil
+}
+
+// checkFileStable returns an error if the given file cannot be organized yet
+// because it is a partial download or because it is still being written,
+// that is its size and modification time, as recorded by observeFile,
+// changed since or were unchanged for less than stableSeconds seconds.
+// The given file is not modified.
+func checkFileStable(file *File, stableSeconds int) error {
+	if isPartialDownload(file.Name) {
+		return fmt.Errorf("file %q is a partial download", file.Name)
+	}
+
+	info, err := os.Stat(file.Path)
+	if err != nil {
+		return err
+	}
+	if info.Size() != file.Size || !info.ModTime().Equal(file.modTime) {
+		return fmt.Errorf("file %q is still being written", file.Name)
+	}
+
+	if stableSeconds == 0 {
+		stableSeconds = defaultStableSeconds
+	}
+	interval := time.Duration(stableSeconds) * time.Second
+	if file.unchangedSince.IsZero() || time.Since(file.unchangedSince) < interval {
+		return fmt.Errorf("file %q is still being written", file.Name)
+	}
+	return nil
+}
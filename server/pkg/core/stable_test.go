@@ -0,0 +1,85 @@
+package core
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_isPartialDownload(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.True(isPartialDownload("video.mp4.part"))
+	assert.True(isPartialDownload("setup.exe.CRDOWNLOAD"))
+	assert.True(isPartialDownload("song.mp3.!ut"))
+	assert.False(isPartialDownload("party.jpg"))
+	assert.False(isPartialDownload("report.pdf"))
+}
+
+func Test_checkFileStable(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "dir")
+	assert.Nil(err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "a.txt")
+	err = ioutil.WriteFile(path, []byte("123"), 0644)
+	assert.Nil(err)
+	mtime := time.Now().Add(-time.Hour)
+	err = os.Chtimes(path, mtime, mtime)
+	assert.Nil(err)
+	file := &File{Name: "a.txt", Dir: dir, Path: path, Ext: ".txt", Size: 1}
+
+	// Never observed
+	err = checkFileStable(file, 60)
+	assert.NotNil(err)
+	assert.Equal(int64(1), file.Size)
+
+	// Just observed, even if modified long ago
+	err = observeFile(file)
+	assert.Nil(err)
+	assert.Equal(int64(3), file.Size)
+	err = checkFileStable(file, 60)
+	assert.NotNil(err)
+
+	// Unchanged for long enough
+	file.unchangedSince = time.Now().Add(-time.Minute)
+	err = checkFileStable(file, 0)
+	assert.Nil(err)
+	err = checkFileStable(file, 120)
+	assert.NotNil(err)
+
+	// Changed since observed, with the modification time kept
+	err = ioutil.WriteFile(path, []byte("12345"), 0644)
+	assert.Nil(err)
+	err = os.Chtimes(path, mtime, mtime)
+	assert.Nil(err)
+	err = checkFileStable(file, 0)
+	assert.NotNil(err)
+	assert.Equal(int64(3), file.Size)
+	err = observeFile(file)
+	assert.Nil(err)
+	assert.Equal(int64(5), file.Size)
+	err = checkFileStable(file, 0)
+	assert.NotNil(err)
+
+	// Observed again unchanged
+	file.unchangedSince = time.Now().Add(-time.Minute)
+	err = observeFile(file)
+	assert.Nil(err)
+	err = checkFileStable(file, 0)
+	assert.Nil(err)
+
+	// Partial download
+	err = checkFileStable(&File{Name: "a.txt.part", Dir: dir, Path: path}, 0)
+	assert.NotNil(err)
+
+	// Missing file
+	err = checkFileStable(&File{Name: "b.txt", Dir: dir, Path: filepath.Join(dir, "b.txt")}, 0)
+	assert.NotNil(err)
+}
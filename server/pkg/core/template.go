@@ -0,0 +1,238 @@
+package core
+
+import (
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Template tokens.
+const (
+	templateTokenName    = "name"
+	templateTokenExt     = "ext"
+	templateTokenCounter = "counter"
+	templateTokenMtime   = "mtime"
+	templateTokenSubdir  = "subdir"
+	templateTokenHash    = "hash"
+)
+
+// Template token defaults.
+const (
+	templateDefaultCounterWidth = 1
+	templateDefaultMtimeLayout  = "2006-01-02"
+	templateDefaultHashLength   = 8
+	templateMaxHashLength       = 64
+)
+
+// templateToken represents a token in a template,
+// for example {counter:3}, with name "counter" and argument "3".
+type templateToken struct {
+	name string
+	arg  string
+}
+
+// templatePart represents either a literal string or a token in a template.
+type templatePart struct {
+	literal string
+	token   *templateToken
+}
+
+// templateVars contains the values that replace the tokens in a template.
+type templateVars struct {
+	file    *File
+	srcDir  string
+	counter int
+}
+
+// parseTemplate parses a template made of literal text and tokens in braces:
+//
+//	{name}          the file name without extension
+//	{ext}           the file extension without the leading dot
+//	{counter:N}     a counter, zero-padded to N digits
+//	{mtime:LAYOUT}  the modification time formatted with the Go time layout LAYOUT,
+//	                for example {mtime:2006-01-02} or {mtime:01}
+//	{subdir}        the file's directory, relative to the source directory
+//	{hash:N}        the first N hexadecimal digits of the SHA-256 hash of the file's content
+//
+// The arguments are optional.
+func parseTemplate(tmpl string) ([]*templatePart, error) {
+	var parts []*templatePart
+	for len(tmpl) > 0 {
+		start := strings.IndexAny(tmpl, "{}")
+		if start == -1 {
+			parts = append(parts, &templatePart{literal: tmpl})
+			break
+		}
+		if tmpl[start] == '}' {
+			return nil, fmt.Errorf("unexpected '}' in template")
+		}
+		if start > 0 {
+			parts = append(parts, &templatePart{literal: tmpl[:start]})
+		}
+
+		end := strings.IndexAny(tmpl[start+1:], "{}")
+		if end == -1 || tmpl[start+1+end] != '}' {
+			return nil, fmt.Errorf("unclosed '{' in template")
+		}
+		token, err := parseTemplateToken(tmpl[start+1 : start+1+end])
+		if err != nil {
+			return nil, err
+		}
+		parts = append(parts, &templatePart{token: token})
+		tmpl = tmpl[start+1+end+1:]
+	}
+	return parts, nil
+}
+
+func parseTemplateToken(s string) (*templateToken, error) {
+	token := &templateToken{name: s}
+	if i := strings.Index(s, ":"); i != -1 {
+		token.name, token.arg = s[:i], s[i+1:]
+	}
+
+	switch token.name {
+	case templateTokenName, templateTokenExt, templateTokenSubdir:
+		if token.arg != "" {
+			return nil, fmt.Errorf("token %q takes no argument", token.name)
+		}
+	case templateTokenCounter, templateTokenHash:
+		if token.arg == "" {
+			break
+		}
+		n, err := strconv.Atoi(token.arg)
+		if err != nil || n < 1 || (token.name == templateTokenHash && n > templateMaxHashLength) {
+			return nil, fmt.Errorf("token %q has an invalid argument %q", token.name, token.arg)
+		}
+	case templateTokenMtime:
+	default:
+		return nil, fmt.Errorf("unknown token %q", token.name)
+	}
+	return token, nil
+}
+
+// renderTemplate replaces the tokens in the given template with the given values.
+func renderTemplate(parts []*templatePart, vars *templateVars) (string, error) {
+	var b strings.Builder
+	for _, p := range parts {
+		if p.token == nil {
+			b.WriteString(p.literal)
+			continue
+		}
+		value, err := templateTokenValue(p.token, vars)
+		if err != nil {
+			return "", err
+		}
+		b.WriteString(value)
+	}
+	return b.String(), nil
+}
+
+func templateTokenValue(token *templateToken, vars *templateVars) (string, error) {
+	file := vars.file
+	switch token.name {
+	case templateTokenName:
+		return strings.TrimSuffix(file.Name, file.Ext), nil
+	case templateTokenExt:
+		return strings.TrimPrefix(file.Ext, "."), nil
+	case templateTokenCounter:
+		width := templateDefaultCounterWidth
+		if token.arg != "" {
+			width, _ = strconv.Atoi(token.arg)
+		}
+		return fmt.Sprintf("%0*d", width, vars.counter), nil
+	case templateTokenMtime:
+		layout := token.arg
+		if layout == "" {
+			layout = templateDefaultMtimeLayout
+		}
+		info, err := os.Stat(file.Path)
+		if err != nil {
+			return "", err
+		}
+		return info.ModTime().Format(layout), nil
+	case templateTokenSubdir:
+		subdir, err := filepath.Rel(vars.srcDir, file.Dir)
+		if err != nil {
+			return "", err
+		}
+		if subdir == "." {
+			return "", nil
+		}
+		return subdir, nil
+	case templateTokenHash:
+		length := templateDefaultHashLength
+		if token.arg != "" {
+			length, _ = strconv.Atoi(token.arg)
+		}
+		hash, err := fileHash(file.Path)
+		if err != nil {
+			return "", err
+		}
+		return hex.EncodeToString(hash)[:length], nil
+	default:
+		return "", fmt.Errorf("unknown token %q", token.name)
+	}
+}
+
+// renderFileName renders the given rename template for the given file.
+// Path separators are replaced with underscores and, if the file has no extension,
+// a dot right before an {ext} token is dropped.
+func renderFileName(tmpl string, vars *templateVars) (string, error) {
+	parts, err := parseTemplate(tmpl)
+	if err != nil {
+		return "", err
+	}
+	if vars.file.Ext == "" {
+		parts = dropDotBeforeExt(parts)
+	}
+	name, err := renderTemplate(parts, vars)
+	if err != nil {
+		return "", err
+	}
+	name = strings.NewReplacer("/", "_", string(filepath.Separator), "_").Replace(name)
+	if name == "" || name == "." || name == ".." {
+		return "", fmt.Errorf("template %q renders an invalid file name %q", tmpl, name)
+	}
+	return name, nil
+}
+
+// renderSubpath renders the given subpath template for the given file,
+// for example "{mtime:2006}/{mtime:01}", returning a path relative to
+// the destination directory; empty path elements are dropped.
+func renderSubpath(tmpl string, vars *templateVars) (string, error) {
+	parts, err := parseTemplate(tmpl)
+	if err != nil {
+		return "", err
+	}
+	subpath, err := renderTemplate(parts, vars)
+	if err != nil {
+		return "", err
+	}
+	subpath = filepath.Clean(filepath.FromSlash(subpath))
+	escapes := filepath.IsAbs(subpath) || filepath.VolumeName(subpath) != "" ||
+		subpath == ".." || strings.HasPrefix(subpath, ".."+string(filepath.Separator))
+	if escapes {
+		return "", fmt.Errorf("template %q renders a path %q outside the destination directory", tmpl, subpath)
+	}
+	if subpath == "." {
+		return "", nil
+	}
+	return subpath, nil
+}
+
+func dropDotBeforeExt(parts []*templatePart) []*templatePart {
+	dropped := make([]*templatePart, len(parts))
+	for i, p := range parts {
+		dropped[i] = p
+		next := i + 1
+		isDotBeforeExt := p.token == nil && strings.HasSuffix(p.literal, ".") &&
+			next < len(parts) && parts[next].token != nil && parts[next].token.name == templateTokenExt
+		if isDotBeforeExt {
+			dropped[i] = &templatePart{literal: strings.TrimSuffix(p.literal, ".")}
+		}
+	}
+	return dropped
+}
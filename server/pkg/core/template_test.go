@@ -0,0 +1,119 @@
+package core
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_parseTemplate(t *testing.T) {
+	assert := assert.New(t)
+
+	tests := []struct {
+		name    string
+		tmpl    string
+		wantErr bool
+	}{
+		{"empty", "", false},
+		{"literal", "photo.jpg", false},
+		{"all tokens", "{mtime:2006-01-02}_{name}_{counter:3}_{subdir}_{hash:6}.{ext}", false},
+		{"default arguments", "{mtime}_{counter}_{hash}", false},
+		{"unknown token", "{size}", true},
+		{"unclosed brace", "{name", true},
+		{"nested brace", "{na{me}", true},
+		{"unexpected brace", "name}", true},
+		{"argument not allowed", "{name:3}", true},
+		{"invalid counter width", "{counter:x}", true},
+		{"zero counter width", "{counter:0}", true},
+		{"hash too long", "{hash:65}", true},
+	}
+	for _, tt := range tests {
+		_, err := parseTemplate(tt.tmpl)
+		assert.Equal(tt.wantErr, err != nil, tt.name)
+	}
+}
+
+func Test_renderFileName(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "dir")
+	assert.Nil(err)
+	defer os.RemoveAll(dir)
+	subdir := filepath.Join(dir, "scans", "2024")
+	err = os.MkdirAll(subdir, 0755)
+	assert.Nil(err)
+
+	mtime := time.Date(2024, 3, 15, 10, 0, 0, 0, time.Local)
+	newFile := func(name string) *File {
+		path := filepath.Join(subdir, name)
+		err := ioutil.WriteFile(path, []byte("123"), 0644)
+		assert.Nil(err)
+		err = os.Chtimes(path, mtime, mtime)
+		assert.Nil(err)
+		return &File{Name: name, Dir: subdir, Path: path, Ext: filepath.Ext(name)}
+	}
+	pdf := newFile("invoice.pdf")
+	noExt := newFile("README")
+
+	tests := []struct {
+		name    string
+		tmpl    string
+		file    *File
+		counter int
+		want    string
+		wantErr bool
+	}{
+		{"scan", "{mtime:2006-01-02}_{name}_{counter:3}.{ext}", pdf, 3, "2024-03-15_invoice_003.pdf", false},
+		{"no extension", "{name}_{counter}.{ext}", noExt, 12, "README_12", false},
+		{"subdir", "{subdir}_{name}.{ext}", pdf, 1, "scans_2024_invoice.pdf", false},
+		{"hash", "{hash:6}.{ext}", pdf, 1, "a665a4.pdf", false},
+		{"empty name", "{subdir}", &File{Name: "a", Dir: dir}, 1, "", true},
+	}
+	for _, tt := range tests {
+		got, err := renderFileName(tt.tmpl, &templateVars{file: tt.file, srcDir: dir, counter: tt.counter})
+		assert.Equal(tt.wantErr, err != nil, tt.name)
+		assert.Equal(tt.want, got, tt.name)
+	}
+}
+
+func Test_renderSubpath(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "dir")
+	assert.Nil(err)
+	defer os.RemoveAll(dir)
+
+	mtime := time.Date(2024, 3, 15, 10, 0, 0, 0, time.Local)
+	path := filepath.Join(dir, "invoice.pdf")
+	err = ioutil.WriteFile(path, []byte("123"), 0644)
+	assert.Nil(err)
+	err = os.Chtimes(path, mtime, mtime)
+	assert.Nil(err)
+	pdf := &File{Name: "invoice.pdf", Dir: dir, Path: path, Ext: ".pdf"}
+	noExt := &File{Name: "README", Dir: dir, Path: path, Ext: ""}
+
+	tests := []struct {
+		name    string
+		tmpl    string
+		file    *File
+		want    string
+		wantErr bool
+	}{
+		{"year and month", "{mtime:2006}/{mtime:01}", pdf, filepath.Join("2024", "03"), false},
+		{"extension", "{ext}", pdf, "pdf", false},
+		{"no extension", "{ext}", noExt, "", false},
+		{"empty elements", "docs/{subdir}/{ext}", pdf, filepath.Join("docs", "pdf"), false},
+		{"absolute", "/{ext}", pdf, "", true},
+		{"outside", "{ext}/../..", pdf, "", true},
+		{"invalid", "{year}", pdf, "", true},
+	}
+	for _, tt := range tests {
+		got, err := renderSubpath(tt.tmpl, &templateVars{file: tt.file, srcDir: dir, counter: 1})
+		assert.Equal(tt.wantErr, err != nil, tt.name)
+		assert.Equal(tt.want, got, tt.name)
+	}
+}
@@ -0,0 +1,123 @@
+package core
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/velut/fsutils-go/fs"
+)
+
+// Trash directory layout, see https://specifications.freedesktop.org/trash-spec/trashspec-latest.html.
+const (
+	trashFilesDir        = "files"
+	trashInfoDir         = "info"
+	trashInfoExt         = ".trashinfo"
+	trashInfoDeletionFmt = "2006-01-02T15:04:05"
+)
+
+// trashDir returns the user's home trash directory,
+// that is $XDG_DATA_HOME/Trash, defaulting to $HOME/.local/share/Trash.
+func trashDir() (string, error) {
+	dataHome := os.Getenv("XDG_DATA_HOME")
+	if !filepath.IsAbs(dataHome) {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		dataHome = filepath.Join(home, ".local", "share")
+	}
+	return filepath.Join(dataHome, "Trash"), nil
+}
+
+// trashFilesPath returns the path of the file with the given name
+// in the files directory of the user's home trash.
+func trashFilesPath(name string) (string, error) {
+	dir, err := trashDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, trashFilesDir, name), nil
+}
+
+// trashFile moves the file at srcPath to dstPath, inside the files directory of a trash,
+// writing the corresponding trash info file.
+// If dstPath's name is already used, trashFile tries other names
+// in incrementing order, up to maxTries times, skipping the names for which skip returns true.
+// trashFile returns the path of the trashed file.
+func trashFile(srcPath, dstPath string, maxTries int, skip func(string) bool) (string, error) {
+	srcPath, err := filepath.Abs(srcPath)
+	if err != nil {
+		return "", err
+	}
+	filesDir, name := filepath.Split(filepath.Clean(dstPath))
+	infoDir := filepath.Join(filepath.Dir(filepath.Clean(filesDir)), trashInfoDir)
+	for _, dir := range []string{filesDir, infoDir} {
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			return "", err
+		}
+	}
+
+	for i := 0; i <= maxTries; i++ {
+		candidate := insertCounter(name, i)
+		candidatePath := filepath.Join(filesDir, candidate)
+		if skip != nil && skip(candidatePath) {
+			continue
+		}
+
+		// Creating the info file first reserves the name, as required by the spec.
+		infoPath := filepath.Join(infoDir, candidate+trashInfoExt)
+		info, err := os.OpenFile(infoPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+		if os.IsExist(err) {
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+		_, err = fmt.Fprintf(info, "[Trash Info]\nPath=%s\nDeletionDate=%s\n",
+			trashInfoPath(srcPath), time.Now().Format(trashInfoDeletionFmt))
+		if closeErr := info.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			_ = os.Remove(infoPath)
+			return "", err
+		}
+
+		if _, err := fs.MoveFileSafe(srcPath, candidatePath, 0); err != nil {
+			_ = os.Remove(infoPath)
+			if err == fs.MaxTriesErr {
+				continue
+			}
+			return "", err
+		}
+		return candidatePath, nil
+	}
+	return "", fs.MaxTriesErr
+}
+
+// untrashFile moves the trashed file at trashedPath back to origPath
+// and removes its trash info file.
+func untrashFile(trashedPath, origPath string) error {
+	if _, err := fs.MoveFileSafe(trashedPath, origPath, 0); err != nil {
+		return err
+	}
+	filesDir, name := filepath.Split(filepath.Clean(trashedPath))
+	infoPath := filepath.Join(filepath.Dir(filepath.Clean(filesDir)), trashInfoDir, name+trashInfoExt)
+	if err := os.Remove(infoPath); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// trashInfoPath returns the given absolute path percent-encoded for a trash info file.
+func trashInfoPath(path string) string {
+	segments := strings.Split(filepath.ToSlash(path), "/")
+	for i, s := range segments {
+		segments[i] = url.PathEscape(s)
+	}
+	return strings.Join(segments, "/")
+}
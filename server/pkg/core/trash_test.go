@@ -0,0 +1,89 @@
+package core
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_trashDir(t *testing.T) {
+	assert := assert.New(t)
+
+	prev, ok := os.LookupEnv("XDG_DATA_HOME")
+	defer func() {
+		if ok {
+			os.Setenv("XDG_DATA_HOME", prev)
+		} else {
+			os.Unsetenv("XDG_DATA_HOME")
+		}
+	}()
+
+	os.Setenv("XDG_DATA_HOME", "/data")
+	dir, err := trashDir()
+	assert.Nil(err)
+	assert.Equal(filepath.Join("/data", "Trash"), dir)
+
+	// Relative paths are ignored, as required by the XDG base directory spec
+	os.Setenv("XDG_DATA_HOME", "data")
+	dir, err = trashDir()
+	assert.Nil(err)
+	assert.True(strings.HasSuffix(dir, filepath.Join(".local", "share", "Trash")), dir)
+}
+
+func Test_trashFile(t *testing.T) {
+	assert := assert.New(t)
+
+	srcDir, err := ioutil.TempDir("", "dir")
+	assert.Nil(err)
+	defer os.RemoveAll(srcDir)
+	trash, err := ioutil.TempDir("", "trash")
+	assert.Nil(err)
+	defer os.RemoveAll(trash)
+
+	srcPath := filepath.Join(srcDir, "a b%.txt")
+	otherSrcPath := filepath.Join(srcDir, "sub", "a b%.txt")
+	assert.Nil(os.Mkdir(filepath.Dir(otherSrcPath), 0755))
+	for _, p := range []string{srcPath, otherSrcPath} {
+		assert.Nil(ioutil.WriteFile(p, []byte("123"), 0644))
+	}
+
+	dstPath := filepath.Join(trash, trashFilesDir, "a b%.txt")
+	trashedPath, err := trashFile(srcPath, dstPath, 1, nil)
+	assert.Nil(err)
+	assert.Equal(dstPath, trashedPath)
+	assert.FileExists(trashedPath)
+	_, err = os.Stat(srcPath)
+	assert.True(os.IsNotExist(err))
+
+	info, err := ioutil.ReadFile(filepath.Join(trash, trashInfoDir, "a b%.txt"+trashInfoExt))
+	assert.Nil(err)
+	lines := strings.Split(string(info), "\n")
+	assert.Equal("[Trash Info]", lines[0])
+	assert.Equal("Path="+trashInfoPath(srcPath), lines[1])
+	assert.True(strings.HasSuffix(lines[1], "/a%20b%25.txt"), lines[1])
+	assert.True(strings.HasPrefix(lines[2], "DeletionDate="), lines[2])
+
+	// Same name, renamed
+	otherTrashedPath, err := trashFile(otherSrcPath, dstPath, 1, nil)
+	assert.Nil(err)
+	assert.Equal(filepath.Join(trash, trashFilesDir, "a b%(1).txt"), otherTrashedPath)
+	assert.FileExists(filepath.Join(trash, trashInfoDir, "a b%(1).txt"+trashInfoExt))
+
+	// No names left
+	assert.Nil(ioutil.WriteFile(srcPath, []byte("123"), 0644))
+	_, err = trashFile(srcPath, dstPath, 1, nil)
+	assert.NotNil(err)
+	assert.FileExists(srcPath)
+	assert.Nil(os.Remove(srcPath))
+
+	// Restore
+	err = untrashFile(trashedPath, srcPath)
+	assert.Nil(err)
+	assert.FileExists(srcPath)
+	_, err = os.Stat(filepath.Join(trash, trashInfoDir, "a b%.txt"+trashInfoExt))
+	assert.True(os.IsNotExist(err))
+}
@@ -0,0 +1,173 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/velut/fsutils-go/fs"
+)
+
+// watchDebounce is how long the watcher waits for further changes
+// in the source directory before reading it again.
+const watchDebounce = 200 * time.Millisecond
+
+// startWatcher starts watching the source directories of the current organizer,
+// and their subdirectories if included, adding new files to the queue
+// and marking the vanished ones as gone.
+func (o *Organizer) startWatcher() {
+	inner := o.organizer
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return
+	}
+	for _, d := range inner.config.Src.srcDirs() {
+		if err := watchDirs(watcher, d.Dir, d.IncludeSubdirs); err != nil {
+			_ = watcher.Close()
+			return
+		}
+	}
+	inner.watcher = watcher
+	go o.watchSrcDirs(inner, watcher)
+}
+
+// watchDirs adds the given directory, and its subdirectories if includeSubdirs is true,
+// to the watched directories.
+func watchDirs(watcher *fsnotify.Watcher, dir string, includeSubdirs bool) error {
+	if !includeSubdirs {
+		return watcher.Add(dir)
+	}
+	return filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			// Unreadable subdirectories are skipped, like in fs.ReadDir.
+			if path == dir {
+				return err
+			}
+			return nil
+		}
+		if info.IsDir() {
+			_ = watcher.Add(path)
+		}
+		return nil
+	})
+}
+
+// watchSrcDirs reads the source directories again after every burst of changes,
+// until the watcher is closed.
+func (o *Organizer) watchSrcDirs(inner *organizer, watcher *fsnotify.Watcher) {
+	src := inner.config.Src
+	var debounce <-chan time.Time
+	for {
+		select {
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+			if event.Op&fsnotify.Create != 0 && includesSubdir(src, event.Name) {
+				_ = watchDirs(watcher, event.Name, true)
+			}
+			debounce = time.After(watchDebounce)
+		case _, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+		case <-debounce:
+			debounce = nil
+			fileInfos, srcIDs, err := readSrcDirs(src)
+			if err != nil {
+				continue
+			}
+			o.syncFiles(inner, fileInfos, srcIDs)
+		}
+	}
+}
+
+// includesSubdir returns true if the directory at the given path is a subdirectory
+// of a source directory including its subdirectories.
+func includesSubdir(src *ConfigSrc, path string) bool {
+	if !isDir(path) {
+		return false
+	}
+	for _, d := range src.srcDirs() {
+		if !d.IncludeSubdirs {
+			continue
+		}
+		if isChild, err := fs.SubdirOf(path, d.Dir); err == nil && isChild {
+			return true
+		}
+	}
+	return false
+}
+
+func (o *Organizer) syncFiles(inner *organizer, fileInfos []*fs.FileInfo, srcIDs map[string]int) {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	// The configuration was dropped while reading the source directory.
+	if o.organizer != inner {
+		return
+	}
+	if inner.syncFiles(fileInfos, srcIDs) {
+		o.saveSession()
+	}
+}
+
+// syncFiles updates the organizer's files with the given files read from the source directories,
+// with the given source IDs by path, returning true if the files changed.
+// New files are appended, in the configured order; files vanished from the source directory,
+// without being sent to a destination directory, are marked as gone until they reappear.
+// If the configuration requires stable files, the sizes and modification times
+// of the files still in the source directory are recorded.
+func (o *organizer) syncFiles(fileInfos []*fs.FileInfo, srcIDs map[string]int) bool {
+	changed := false
+
+	found := make(map[string]bool, len(fileInfos))
+	for _, fi := range fileInfos {
+		found[fi.Path] = true
+	}
+	submitted := o.submittedFileIndexes()
+	known := make(map[string]bool, len(o.files))
+	for i, f := range o.files {
+		known[f.Path] = true
+		// Existence is checked again, as the files may have changed
+		// while reading the source directory, for example after a rename.
+		gone := false
+		if !found[f.Path] {
+			_, err := os.Lstat(f.Path)
+			gone = os.IsNotExist(err)
+		}
+		// Files still being written are observed until they are stable.
+		if !gone && o.config.Src.CheckStable {
+			_ = observeFile(f)
+		}
+		if gone == f.Gone || (gone && submitted[i]) {
+			continue
+		}
+		f.Gone = gone
+		changed = true
+	}
+
+	var added []*fs.FileInfo
+	for _, fi := range fileInfos {
+		if known[fi.Path] {
+			continue
+		}
+		if _, err := os.Lstat(fi.Path); err == nil {
+			added = append(added, fi)
+		}
+	}
+	sortFiles(added, o.config.Src.Sort, o.sortSeed)
+	for _, fi := range added {
+		o.files = append(o.files, o.newFile(int64(len(o.files)+1), srcIDs[fi.Path], fi))
+		o.numAddedFiles++
+		changed = true
+	}
+	return changed
+}
+
+func (o *organizer) stopWatcher() {
+	if o.watcher != nil {
+		_ = o.watcher.Close()
+	}
+}